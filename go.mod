@@ -5,8 +5,10 @@ go 1.14
 require (
 	github.com/SAP/go-hdb v0.105.8
 	github.com/google/uuid v1.3.0
+	github.com/jinzhu/inflection v1.0.0
 	github.com/jinzhu/now v1.1.5 // indirect
 	github.com/stretchr/testify v1.7.0
 	golang.org/x/crypto v0.0.0-20220321153916-2c7772ba3064 // indirect
+	gopkg.in/yaml.v3 v3.0.1
 	gorm.io/gorm v1.23.3
 )
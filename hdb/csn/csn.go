@@ -0,0 +1,617 @@
+// Package csn reads SAP CAP data models in CSN, the JSON form of compiled
+// CDS, and maps their entities to the HANA tables CAP deploys for them:
+//
+//	model, err := csn.Load("gen/srv/srv/csn.json")
+//	src, err := csn.Generate(model, gormgen.Config{Package: "bookshop"})
+//
+// Tables and columns are named like CAP's default plain naming, so
+// my.bookshop.Books becomes MY_BOOKSHOP_BOOKS and the managed association
+// author becomes the column AUTHOR_ID. The cuid, managed and temporal aspects
+// and the types of @sap/cds/common are known without their definitions,
+// localized elements get the texts tables CAP generates.
+package csn
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	hdb "gorm.io/driver/hana/hdb"
+	"gorm.io/driver/hana/hdb/gormgen"
+)
+
+// Model is a CSN document
+type Model struct {
+	Namespace   string                 `json:"namespace,omitempty"`
+	Definitions map[string]*Definition `json:"definitions"`
+}
+
+// Definition is an entity, aspect or type definition of a model
+type Definition struct {
+	Kind     string   `json:"kind"`
+	Includes []string `json:"includes,omitempty"`
+	// Query and Projection are the queries of views
+	Query      json.RawMessage `json:"query,omitempty"`
+	Projection json.RawMessage `json:"projection,omitempty"`
+	// Skip is set by @cds.persistence.skip for definitions without table
+	Skip bool `json:"@cds.persistence.skip,omitempty"`
+	Element
+}
+
+// Element is an element of an entity or the type of a type definition
+type Element struct {
+	Type      string `json:"type,omitempty"`
+	Key       bool   `json:"key,omitempty"`
+	NotNull   bool   `json:"notNull,omitempty"`
+	Virtual   bool   `json:"virtual,omitempty"`
+	Localized bool   `json:"localized,omitempty"`
+	Length    int    `json:"length,omitempty"`
+	Precision int    `json:"precision,omitempty"`
+	Scale     int    `json:"scale,omitempty"`
+	// Target, Keys, On and Cardinality describe associations and compositions
+	Target      string          `json:"target,omitempty"`
+	Keys        []ForeignKey    `json:"keys,omitempty"`
+	On          json.RawMessage `json:"on,omitempty"`
+	Cardinality *Cardinality    `json:"cardinality,omitempty"`
+	Default     *Value          `json:"default,omitempty"`
+	// Elements are the elements of structured types
+	Elements Elements `json:"elements,omitempty"`
+	// Items is the type of arrayed elements
+	Items *Element `json:"items,omitempty"`
+	Doc   string   `json:"doc,omitempty"`
+}
+
+// ForeignKey is a key of a managed association
+type ForeignKey struct {
+	Ref []string `json:"ref"`
+	As  string   `json:"as,omitempty"`
+}
+
+// Cardinality is the cardinality of an association, Max is * or a number
+type Cardinality struct {
+	Max interface{} `json:"max,omitempty"`
+}
+
+// Value is a literal value
+type Value struct {
+	Val interface{} `json:"val"`
+}
+
+// NamedElement is an element with its name
+type NamedElement struct {
+	Name string
+	Element
+}
+
+// Elements are the elements of a definition in declaration order
+type Elements []NamedElement
+
+// UnmarshalJSON reads the elements object keeping the order of its members
+func (e *Elements) UnmarshalJSON(b []byte) error {
+	decoder := json.NewDecoder(strings.NewReader(string(b)))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return fmt.Errorf("csn: elements are no object")
+	}
+	*e = nil
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		element := NamedElement{Name: token.(string)}
+		if err := decoder.Decode(&element.Element); err != nil {
+			return err
+		}
+		*e = append(*e, element)
+	}
+	return nil
+}
+
+// MarshalJSON writes the elements as object in declaration order
+func (e Elements) MarshalJSON() ([]byte, error) {
+	var builder strings.Builder
+	builder.WriteByte('{')
+	for i, element := range e {
+		if i > 0 {
+			builder.WriteByte(',')
+		}
+		builder.WriteString(strconv.Quote(element.Name))
+		builder.WriteByte(':')
+		b, err := json.Marshal(element.Element)
+		if err != nil {
+			return nil, err
+		}
+		builder.Write(b)
+	}
+	builder.WriteByte('}')
+	return []byte(builder.String()), nil
+}
+
+func (e Elements) lookup(name string) (Element, bool) {
+	for _, element := range e {
+		if element.Name == name {
+			return element.Element, true
+		}
+	}
+	return Element{}, false
+}
+
+// Load reads a CSN file
+func Load(path string) (*Model, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(b)
+}
+
+// Parse reads a CSN document
+func Parse(b []byte) (*Model, error) {
+	model := &Model{}
+	if err := json.Unmarshal(b, model); err != nil {
+		return nil, fmt.Errorf("csn: %w", err)
+	}
+	return model, nil
+}
+
+// commonModel are the definitions of @sap/cds/common used by CAP models
+var commonModel = mustParse(`{"definitions": {
+	"cuid": {"kind": "aspect", "elements": {"ID": {"key": true, "type": "cds.UUID"}}},
+	"managed": {"kind": "aspect", "elements": {
+		"createdAt": {"type": "cds.Timestamp"}, "createdBy": {"type": "User"},
+		"modifiedAt": {"type": "cds.Timestamp"}, "modifiedBy": {"type": "User"}
+	}},
+	"temporal": {"kind": "aspect", "elements": {
+		"validFrom": {"type": "cds.Timestamp"}, "validTo": {"type": "cds.Timestamp"}
+	}},
+	"User": {"kind": "type", "type": "cds.String", "length": 255},
+	"Language": {"kind": "type", "type": "cds.Association", "target": "sap.common.Languages", "keys": [{"ref": ["code"]}]},
+	"Currency": {"kind": "type", "type": "cds.Association", "target": "sap.common.Currencies", "keys": [{"ref": ["code"]}]},
+	"Country": {"kind": "type", "type": "cds.Association", "target": "sap.common.Countries", "keys": [{"ref": ["code"]}]},
+	"Timezone": {"kind": "type", "type": "cds.Association", "target": "sap.common.Timezones", "keys": [{"ref": ["code"]}]},
+	"sap.common.Locale": {"kind": "type", "type": "cds.String", "length": 14},
+	"sap.common.Languages": {"kind": "entity", "elements": {"code": {"key": true, "type": "sap.common.Locale"}}},
+	"sap.common.Currencies": {"kind": "entity", "elements": {"code": {"key": true, "type": "cds.String", "length": 3}}},
+	"sap.common.Countries": {"kind": "entity", "elements": {"code": {"key": true, "type": "cds.String", "length": 3}}},
+	"sap.common.Timezones": {"kind": "entity", "elements": {"code": {"key": true, "type": "cds.String", "length": 100}}}
+}}`)
+
+func mustParse(s string) *Model {
+	model, err := Parse([]byte(s))
+	if err != nil {
+		panic(err)
+	}
+	return model
+}
+
+// definition returns the definition of name in the model or in @sap/cds/common
+func (m *Model) definition(name string) (*Definition, bool) {
+	if def, ok := m.Definitions[name]; ok {
+		return def, true
+	}
+	def, ok := commonModel.Definitions[name]
+	return def, ok
+}
+
+// TableName returns the HANA table name of the entity name like MY_BOOKSHOP_BOOKS
+func TableName(name string) string {
+	return strings.ToUpper(strings.ReplaceAll(name, ".", "_"))
+}
+
+// hanaTypes are the HANA data types of the CDS types, lengths are default lengths
+var hanaTypes = map[string]struct {
+	dataType string
+	length   int
+}{
+	"cds.UUID":              {"NVARCHAR", 36},
+	"cds.String":            {"NVARCHAR", 5000},
+	"cds.LargeString":       {"NCLOB", 0},
+	"cds.Boolean":           {"BOOLEAN", 0},
+	"cds.UInt8":             {"TINYINT", 0},
+	"cds.Int16":             {"SMALLINT", 0},
+	"cds.Int32":             {"INTEGER", 0},
+	"cds.Integer":           {"INTEGER", 0},
+	"cds.Int64":             {"BIGINT", 0},
+	"cds.Integer64":         {"BIGINT", 0},
+	"cds.Decimal":           {"DECIMAL", 0},
+	"cds.DecimalFloat":      {"DECIMAL", 0},
+	"cds.Double":            {"DOUBLE", 0},
+	"cds.Date":              {"DATE", 0},
+	"cds.Time":              {"TIME", 0},
+	"cds.DateTime":          {"SECONDDATE", 0},
+	"cds.Timestamp":         {"TIMESTAMP", 0},
+	"cds.Binary":            {"VARBINARY", 5000},
+	"cds.LargeBinary":       {"BLOB", 0},
+	"cds.Vector":            {"REAL_VECTOR", 0},
+	"cds.hana.TINYINT":      {"TINYINT", 0},
+	"cds.hana.SMALLINT":     {"SMALLINT", 0},
+	"cds.hana.REAL":         {"REAL", 0},
+	"cds.hana.CHAR":         {"CHAR", 1},
+	"cds.hana.NCHAR":        {"NCHAR", 1},
+	"cds.hana.VARCHAR":      {"VARCHAR", 5000},
+	"cds.hana.CLOB":         {"CLOB", 0},
+	"cds.hana.BINARY":       {"BINARY", 1},
+	"cds.hana.ST_POINT":     {"ST_POINT", 0},
+	"cds.hana.ST_GEOMETRY":  {"ST_GEOMETRY", 0},
+	"cds.hana.SMALLDECIMAL": {"SMALLDECIMAL", 0},
+}
+
+func isAssociation(typ string) bool {
+	return typ == "cds.Association" || typ == "cds.Composition"
+}
+
+// resolve returns the element with the type of its type definitions
+func (m *Model) resolve(e Element) (Element, error) {
+	for depth := 0; ; depth++ {
+		if _, ok := hanaTypes[e.Type]; ok || e.Type == "" || isAssociation(e.Type) {
+			return e, nil
+		}
+		def, ok := m.definition(e.Type)
+		if !ok || def.Kind != "type" || depth > 32 {
+			return e, fmt.Errorf("unknown type %s", e.Type)
+		}
+
+		base := def.Element
+		base.Key, base.NotNull, base.Virtual, base.Localized = e.Key, e.NotNull, e.Virtual, e.Localized
+		base.Default, base.Doc = e.Default, e.Doc
+		if e.Length > 0 {
+			base.Length = e.Length
+		}
+		if e.Precision > 0 {
+			base.Precision, base.Scale = e.Precision, e.Scale
+		}
+		if e.On != nil || e.Keys != nil {
+			base.On, base.Keys = e.On, e.Keys
+		}
+		if e.Cardinality != nil {
+			base.Cardinality = e.Cardinality
+		}
+		e = base
+	}
+}
+
+// elements returns the elements of def with the elements of its aspects,
+// compiled models list them already
+func (m *Model) elements(def *Definition) Elements {
+	var elements Elements
+	for _, include := range def.Includes {
+		aspect, ok := m.definition(include)
+		if !ok {
+			continue
+		}
+		for _, element := range m.elements(aspect) {
+			if _, ok := def.Elements.lookup(element.Name); !ok {
+				if _, ok := elements.lookup(element.Name); !ok {
+					elements = append(elements, element)
+				}
+			}
+		}
+	}
+	return append(elements, def.Elements...)
+}
+
+func toMany(c *Cardinality) bool {
+	switch max := c.Max.(type) {
+	case string:
+		return max == "*"
+	case float64:
+		return max > 1
+	}
+	return false
+}
+
+func defaultValue(v *Value) string {
+	if v == nil || v.Val == nil {
+		return ""
+	}
+	switch val := v.Val.(type) {
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case bool:
+		return strings.ToUpper(strconv.FormatBool(val))
+	}
+	return fmt.Sprint(v.Val)
+}
+
+// table is the table of an entity being mapped
+type table struct {
+	hdb.CatalogTable
+	entity string
+	// fieldNames are the element paths of the columns like shippingAddress_street
+	fieldNames map[string]string
+}
+
+// columns appends the columns of element name to t
+func (m *Model) columns(t *table, name string, e Element, depth int) error {
+	e, err := m.resolve(e)
+	if err != nil {
+		return fmt.Errorf("csn: %s.%s: %w", t.entity, name, err)
+	}
+	if e.Virtual {
+		return nil
+	}
+	if depth > 32 {
+		return fmt.Errorf("csn: %s.%s: recursive keys", t.entity, name)
+	}
+
+	switch {
+	case isAssociation(e.Type):
+		// unmanaged and to many associations have no columns
+		if e.On != nil || (e.Cardinality != nil && toMany(e.Cardinality)) {
+			return nil
+		}
+		return m.foreignKeyColumns(t, name, e, depth)
+	case e.Type == "" && len(e.Elements) > 0:
+		for _, sub := range e.Elements {
+			sub.Key = sub.Key || e.Key
+			if err := m.columns(t, name+"_"+sub.Name, sub.Element, depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	case e.Items != nil:
+		// arrayed elements are stored as JSON documents
+		e = Element{Type: "cds.LargeString", Key: e.Key, NotNull: e.NotNull, Doc: e.Doc}
+	}
+
+	hanaType, ok := hanaTypes[e.Type]
+	if !ok {
+		return fmt.Errorf("csn: %s.%s: unsupported type %q", t.entity, name, e.Type)
+	}
+	column := hdb.CatalogColumn{
+		Name:         TableName(name),
+		DataType:     hanaType.dataType,
+		Nullable:     !e.Key && !e.NotNull,
+		DefaultValue: defaultValue(e.Default),
+		Comment:      e.Doc,
+	}
+	switch {
+	case hanaType.dataType == "DECIMAL":
+		column.Length, column.Scale = e.Precision, e.Scale
+	case hanaType.dataType == "REAL_VECTOR":
+		column.Length = e.Length
+	case hanaType.length > 0:
+		column.Length = hanaType.length
+		if e.Length > 0 {
+			column.Length = e.Length
+		}
+	}
+	t.Columns = append(t.Columns, column)
+	if t.fieldNames == nil {
+		t.fieldNames = map[string]string{}
+	}
+	t.fieldNames[column.Name] = name
+	if e.Key {
+		t.PrimaryKey = append(t.PrimaryKey, column.Name)
+	}
+	return nil
+}
+
+// foreignKeyColumns appends the foreign key columns of the managed association name to t
+func (m *Model) foreignKeyColumns(t *table, name string, e Element, depth int) error {
+	target, ok := m.definition(e.Target)
+	if !ok {
+		return fmt.Errorf("csn: %s.%s: unknown target %s", t.entity, name, e.Target)
+	}
+
+	keys := e.Keys
+	if keys == nil {
+		for _, element := range m.elements(target) {
+			if element.Key {
+				keys = append(keys, ForeignKey{Ref: []string{element.Name}})
+			}
+		}
+	}
+
+	fk := hdb.CatalogForeignKey{ReferencedSchema: t.Schema, ReferencedTable: TableName(e.Target)}
+	for _, key := range keys {
+		targetElement, ok := m.elements(target).lookup(key.Ref[0])
+		for _, part := range key.Ref[1:] {
+			if !ok {
+				break
+			}
+			structured, err := m.resolve(targetElement)
+			if err != nil {
+				return fmt.Errorf("csn: %s.%s: %w", e.Target, key.Ref[0], err)
+			}
+			targetElement, ok = structured.Elements.lookup(part)
+		}
+		if !ok {
+			return fmt.Errorf("csn: %s.%s: unknown key %s of %s", t.entity, name, strings.Join(key.Ref, "."), e.Target)
+		}
+		keyName := key.As
+		if keyName == "" {
+			keyName = strings.Join(key.Ref, "_")
+		}
+		targetElement.Key, targetElement.NotNull = e.Key, e.Key || e.NotNull
+		targetElement.Default, targetElement.Doc = nil, ""
+
+		// the referenced columns are the key columns of the target named alike
+		start := len(t.Columns)
+		if err := m.columns(t, name+"_"+keyName, targetElement, depth+1); err != nil {
+			return err
+		}
+		refTable := &table{entity: e.Target}
+		if err := m.columns(refTable, strings.Join(key.Ref, "_"), targetElement, depth+1); err != nil {
+			return err
+		}
+		for i, column := range t.Columns[start:] {
+			fk.Columns = append(fk.Columns, column.Name)
+			fk.ReferencedColumns = append(fk.ReferencedColumns, refTable.Columns[i].Name)
+		}
+	}
+
+	// associations to entities outside of the model have no constraint
+	if def, ok := m.Definitions[e.Target]; ok && isTable(def) {
+		fk.Name = TableName(t.Name + "_" + name)
+		t.ForeignKeys = append(t.ForeignKeys, fk)
+	}
+	return nil
+}
+
+// isTable reports whether def is an entity stored in a table
+func isTable(def *Definition) bool {
+	return def.Kind == "entity" && def.Query == nil && def.Projection == nil && !def.Skip
+}
+
+// textsEntity returns the texts entity CAP generates for the localized elements of def
+func (m *Model) textsEntity(def *Definition) (*Definition, error) {
+	texts := &Definition{Kind: "entity"}
+	texts.Elements = Elements{{Name: "locale", Element: Element{Key: true, Type: "sap.common.Locale"}}}
+	localized := false
+	for _, element := range m.elements(def) {
+		resolved, err := m.resolve(element.Element)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", element.Name, err)
+		}
+		switch {
+		case element.Key:
+			texts.Elements = append(texts.Elements, element)
+		case resolved.Localized && !isAssociation(resolved.Type):
+			element.Localized = false
+			texts.Elements = append(texts.Elements, element)
+			localized = true
+		}
+	}
+	if !localized {
+		return nil, nil
+	}
+	return texts, nil
+}
+
+// structName returns the name of the entity without namespace, like Books.texts
+func structName(name string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		if r := []rune(part); len(r) > 0 && unicode.IsUpper(r[0]) {
+			return strings.Join(parts[i:], ".")
+		}
+	}
+	return parts[len(parts)-1]
+}
+
+// entities returns the entities stored in tables with the generated texts entities
+func (m *Model) entities() (map[string]*Definition, error) {
+	entities := map[string]*Definition{}
+	for name, def := range m.Definitions {
+		if !isTable(def) {
+			continue
+		}
+		entities[name] = def
+
+		if _, ok := m.Definitions[name+".texts"]; ok {
+			continue
+		}
+		texts, err := m.textsEntity(def)
+		if err != nil {
+			return nil, fmt.Errorf("csn: %s.%w", name, err)
+		}
+		if texts != nil {
+			entities[name+".texts"] = texts
+		}
+	}
+	return entities, nil
+}
+
+// Tables returns the tables of the entities in schemaName, which may be empty
+// for the current schema
+func (m *Model) Tables(schemaName string) ([]hdb.CatalogTable, error) {
+	tables, err := m.tables(schemaName)
+	if err != nil {
+		return nil, err
+	}
+	catalogTables := make([]hdb.CatalogTable, len(tables))
+	for i, t := range tables {
+		catalogTables[i] = t.CatalogTable
+	}
+	return catalogTables, nil
+}
+
+// tables returns the tables of the entities sorted by name
+func (m *Model) tables(schemaName string) ([]*table, error) {
+	entities, err := m.entities()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(entities))
+	for name := range entities {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	tables := make([]*table, 0, len(names))
+	for _, name := range names {
+		def := entities[name]
+		t := &table{CatalogTable: hdb.CatalogTable{Schema: schemaName, Name: TableName(name), Comment: def.Doc}, entity: name}
+		for _, element := range m.elements(def) {
+			if err := m.columns(t, element.Name, element.Element, 0); err != nil {
+				return nil, err
+			}
+		}
+
+		// texts reference the entity by its keys
+		parentName := strings.TrimSuffix(name, ".texts")
+		if parent, ok := entities[parentName]; ok && parentName != name {
+			fk := hdb.CatalogForeignKey{Name: t.Name + "_PARENT", ReferencedSchema: schemaName, ReferencedTable: TableName(parentName)}
+			parentTable := &table{entity: name}
+			for _, element := range m.elements(parent) {
+				if element.Key {
+					if err := m.columns(parentTable, element.Name, element.Element, 0); err != nil {
+						return nil, err
+					}
+				}
+			}
+			for _, column := range parentTable.Columns {
+				fk.Columns = append(fk.Columns, column.Name)
+				fk.ReferencedColumns = append(fk.ReferencedColumns, column.Name)
+			}
+			t.ForeignKeys = append(t.ForeignKeys, fk)
+		}
+		tables = append(tables, t)
+	}
+	return tables, nil
+}
+
+// Generate returns the source of the gorm models of the entities, the
+// structs are named by entity like Book for my.bookshop.Books and the fields
+// by element like ShippingAddressStreet for shippingAddress.street
+func Generate(model *Model, config gormgen.Config) ([]byte, error) {
+	tables, err := model.tables("")
+	if err != nil {
+		return nil, err
+	}
+	entities, err := model.entities()
+	if err != nil {
+		return nil, err
+	}
+
+	structNames := map[string]string{}
+	for name := range entities {
+		structNames[TableName(name)] = structName(name)
+	}
+	for table, name := range config.StructNames {
+		structNames[table] = name
+	}
+	config.StructNames = structNames
+
+	catalogTables := make([]hdb.CatalogTable, len(tables))
+	fieldNames := map[string]map[string]string{}
+	for i, t := range tables {
+		catalogTables[i] = t.CatalogTable
+		fieldNames[t.Name] = t.fieldNames
+	}
+	for table, names := range config.FieldNames {
+		if fieldNames[table] == nil {
+			fieldNames[table] = map[string]string{}
+		}
+		for column, name := range names {
+			fieldNames[table][column] = name
+		}
+	}
+	config.FieldNames = fieldNames
+	return gormgen.Generate(catalogTables, config)
+}
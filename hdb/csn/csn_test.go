@@ -0,0 +1,114 @@
+package csn
+
+import (
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	hdb "gorm.io/driver/hana/hdb"
+	"gorm.io/driver/hana/hdb/gormgen"
+)
+
+// regenerate the golden files with
+//
+//	go test ./hdb/csn -update
+var updateGolden = flag.Bool("update", false, "update golden files")
+
+func loadBookshop(t *testing.T) *Model {
+	model, err := Load(filepath.Join("testdata", "bookshop.json"))
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	return model
+}
+
+func TestTables(t *testing.T) {
+	tables, err := loadBookshop(t).Tables("BOOKSHOP")
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	names := make([]string, len(tables))
+	for i, table := range tables {
+		names[i] = table.Name
+	}
+	assert.Equal(t, []string{
+		"MY_BOOKSHOP_AUTHORS", "MY_BOOKSHOP_BOOKS", "MY_BOOKSHOP_BOOKS_TEXTS",
+		"MY_BOOKSHOP_GENRES", "MY_BOOKSHOP_ORDERITEMS", "MY_BOOKSHOP_ORDERS",
+	}, names)
+
+	books := tables[1]
+	assert.Equal(t, "books of the shop", books.Comment)
+	assert.Equal(t, []string{"ID"}, books.PrimaryKey)
+	assert.Equal(t, []hdb.CatalogColumn{
+		{Name: "ID", DataType: "NVARCHAR", Length: 36},
+		{Name: "CREATEDAT", DataType: "TIMESTAMP", Nullable: true},
+		{Name: "CREATEDBY", DataType: "NVARCHAR", Length: 255, Nullable: true},
+		{Name: "MODIFIEDAT", DataType: "TIMESTAMP", Nullable: true},
+		{Name: "MODIFIEDBY", DataType: "NVARCHAR", Length: 255, Nullable: true},
+		{Name: "TITLE", DataType: "NVARCHAR", Length: 111},
+		{Name: "DESCR", DataType: "NCLOB", Nullable: true},
+		{Name: "AUTHOR_ID", DataType: "NVARCHAR", Length: 36, Nullable: true},
+		{Name: "GENRE_ID", DataType: "INTEGER", Nullable: true},
+		{Name: "STOCK", DataType: "INTEGER", Nullable: true, DefaultValue: "0"},
+		{Name: "PRICE", DataType: "DECIMAL", Length: 9, Scale: 2, Nullable: true},
+		{Name: "CURRENCY_CODE", DataType: "NVARCHAR", Length: 3, Nullable: true},
+		{Name: "TAGS", DataType: "NCLOB", Nullable: true},
+	}, books.Columns)
+	assert.Equal(t, []hdb.CatalogForeignKey{
+		{
+			Name: "MY_BOOKSHOP_BOOKS_AUTHOR", Columns: []string{"AUTHOR_ID"},
+			ReferencedSchema: "BOOKSHOP", ReferencedTable: "MY_BOOKSHOP_AUTHORS", ReferencedColumns: []string{"ID"},
+		},
+		{
+			Name: "MY_BOOKSHOP_BOOKS_GENRE", Columns: []string{"GENRE_ID"},
+			ReferencedSchema: "BOOKSHOP", ReferencedTable: "MY_BOOKSHOP_GENRES", ReferencedColumns: []string{"ID"},
+		},
+	}, books.ForeignKeys)
+
+	texts := tables[2]
+	assert.Equal(t, []string{"LOCALE", "ID"}, texts.PrimaryKey)
+	assert.Equal(t, []hdb.CatalogColumn{
+		{Name: "LOCALE", DataType: "NVARCHAR", Length: 14},
+		{Name: "ID", DataType: "NVARCHAR", Length: 36},
+		{Name: "TITLE", DataType: "NVARCHAR", Length: 111},
+		{Name: "DESCR", DataType: "NCLOB", Nullable: true},
+	}, texts.Columns)
+
+	// keys of key associations and structured types are flattened
+	items := tables[4]
+	assert.Equal(t, []string{"PARENT_ID", "POS"}, items.PrimaryKey)
+	assert.Equal(t, "PARENT_ID", items.Columns[0].Name)
+	orders := tables[5]
+	assert.Equal(t, "SHIPPINGADDRESS_STREET", orders.Columns[2].Name)
+	assert.Equal(t, "readable key", orders.Columns[1].Comment)
+}
+
+func TestGenerate(t *testing.T) {
+	src, err := Generate(loadBookshop(t), gormgen.Config{Package: "bookshop"})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	path := filepath.Join("testdata", "bookshop.golden")
+	if *updateGolden {
+		assert.Nil(t, ioutil.WriteFile(path, src, 0644))
+	}
+	golden, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, string(golden), string(src))
+}
+
+func TestUnknownType(t *testing.T) {
+	model, err := Parse([]byte(`{"definitions": {"Notes": {"kind": "entity", "elements": {
+		"ID": {"key": true, "type": "cds.UUID"}, "text": {"type": "my.Text"}
+	}}}}`))
+	if !assert.Nil(t, err) {
+		return
+	}
+	_, err = model.Tables("")
+	assert.EqualError(t, err, "csn: Notes.text: unknown type my.Text")
+}
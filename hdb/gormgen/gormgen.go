@@ -0,0 +1,452 @@
+// Package gormgen generates gorm models from the tables of a HANA catalog:
+//
+//	snapshot, err := db.Migrator().(hdb.Migrator).Snapshot("SALES")
+//	src, err := gormgen.Generate(snapshot.Tables, gormgen.Config{Package: "sales"})
+//
+// Every table becomes a struct with a TableName method, columns become fields
+// with the hdb types and tags which create the same column types, and foreign
+// keys between the generated tables become belongs to, has one and has many
+// associations.
+package gormgen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/jinzhu/inflection"
+
+	hdb "gorm.io/driver/hana/hdb"
+)
+
+const hdbImport = "gorm.io/driver/hana/hdb"
+
+// Config configures the generated source
+type Config struct {
+	// Package is the package name of the generated file, models if empty
+	Package string
+	// QualifiedTableNames returns the table names qualified with their schema from TableName
+	QualifiedTableNames bool
+	// StructNames are the names the struct names are derived from by table
+	// name, like Books for MY_BOOKSHOP_BOOKS, other structs are named by table
+	StructNames map[string]string
+	// FieldNames are the names the field names are derived from by table and
+	// column name, like createdAt for the column CREATEDAT of a table, other
+	// fields are named by column
+	FieldNames map[string]map[string]string
+}
+
+// commonInitialisms are written in upper case in field names
+var commonInitialisms = map[string]bool{
+	"API": true, "CPU": true, "CSV": true, "GUID": true, "HTML": true, "HTTP": true, "ID": true,
+	"IP": true, "JSON": true, "SQL": true, "URI": true, "URL": true, "UTC": true, "UUID": true, "XML": true,
+}
+
+// goName converts a catalog name like CUSTOMER_ID or customerId into CustomerID
+func goName(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var builder strings.Builder
+	for _, word := range words {
+		// split mixed case words like customerId, upper case words are kept together
+		var parts []string
+		if strings.ToUpper(word) == word || strings.ToLower(word) == word {
+			parts = []string{word}
+		} else {
+			start := 0
+			runes := []rune(word)
+			for i := 1; i < len(runes); i++ {
+				if unicode.IsUpper(runes[i]) && !unicode.IsUpper(runes[i-1]) {
+					parts = append(parts, string(runes[start:i]))
+					start = i
+				}
+			}
+			parts = append(parts, string(runes[start:]))
+		}
+
+		for _, part := range parts {
+			if upper := strings.ToUpper(part); commonInitialisms[upper] {
+				builder.WriteString(upper)
+				continue
+			}
+			runes := []rune(strings.ToLower(part))
+			runes[0] = unicode.ToUpper(runes[0])
+			builder.WriteString(string(runes))
+		}
+	}
+
+	s := builder.String()
+	if s == "" || !unicode.IsLetter([]rune(s)[0]) {
+		s = "X" + s
+	}
+	return s
+}
+
+// uniqueName returns name or name with a number suffix which is not in names
+func uniqueName(names map[string]bool, name string) string {
+	unique := name
+	for i := 2; names[unique]; i++ {
+		unique = name + strconv.Itoa(i)
+	}
+	names[unique] = true
+	return unique
+}
+
+// tagValue escapes the separators of gorm tag values
+func tagValue(s string) string {
+	s = strings.ReplaceAll(s, "`", "'")
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return strings.ReplaceAll(s, ";", `\;`)
+}
+
+// qualifiedName returns a dotted name of the non-empty parts, parts with
+// dots or double quotes are quoted
+func qualifiedName(parts ...string) string {
+	var quoted []string
+	for _, part := range parts {
+		if strings.ContainsAny(part, `."`) {
+			part = `"` + strings.ReplaceAll(part, `"`, `""`) + `"`
+		}
+		if part != "" {
+			quoted = append(quoted, part)
+		}
+	}
+	return strings.Join(quoted, ".")
+}
+
+type field struct {
+	name string
+	typ  string
+	tags []string
+}
+
+type model struct {
+	table  hdb.CatalogTable
+	name   string
+	fields []field
+	// columns are the field names of the columns
+	columns map[string]string
+	names   map[string]bool
+}
+
+func (m *model) fieldNames(columns []string) string {
+	names := make([]string, len(columns))
+	for i, c := range columns {
+		names[i] = m.columns[c]
+	}
+	return strings.Join(names, ",")
+}
+
+// isUnique reports whether columns are the primary key or have a unique index
+func (m *model) isUnique(columns []string) bool {
+	equal := func(a, b []string) bool {
+		if len(a) != len(b) {
+			return false
+		}
+		a, b = append([]string(nil), a...), append([]string(nil), b...)
+		sort.Strings(a)
+		sort.Strings(b)
+		for i := range a {
+			if a[i] != b[i] {
+				return false
+			}
+		}
+		return true
+	}
+
+	if equal(columns, m.table.PrimaryKey) {
+		return true
+	}
+	for _, idx := range m.table.Indexes {
+		if idx.Unique && equal(columns, idx.Columns) {
+			return true
+		}
+	}
+	return false
+}
+
+// nullableTypes are the field types which are pointers for nullable columns
+var nullableTypes = map[string]bool{
+	"uint8": true, "int16": true, "int32": true, "int64": true, "float32": true, "float64": true,
+	"bool": true, "string": true, "time.Time": true,
+	"hdb.Alphanum": true, "hdb.Date": true, "hdb.Time": true, "hdb.SecondDate": true, "hdb.Point": true,
+}
+
+// columnField returns the field type and type tags of column
+func columnField(column hdb.CatalogColumn) (string, []string) {
+	size := func(dataType string) []string {
+		tags := []string{}
+		if dataType != "" {
+			tags = append(tags, "stringtype:"+dataType)
+		}
+		if column.Length > 0 {
+			tags = append(tags, "size:"+strconv.Itoa(column.Length))
+		}
+		return tags
+	}
+	typeTag := func(dataType string) []string {
+		return []string{"type:" + dataType}
+	}
+
+	switch dataType := strings.ToLower(column.DataType); dataType {
+	case "tinyint":
+		return "uint8", nil
+	case "smallint":
+		return "int16", nil
+	case "integer":
+		return "int32", nil
+	case "bigint":
+		return "int64", nil
+	case "real":
+		return "float32", typeTag(dataType)
+	case "double", "float":
+		return "float64", nil
+	case "decimal":
+		if column.Length > 0 {
+			return "hdb.Decimal", []string{"precision:" + strconv.Itoa(column.Length), "scale:" + strconv.Itoa(column.Scale)}
+		}
+		return "hdb.Decimal", nil
+	case "smalldecimal":
+		return "hdb.Decimal", typeTag(dataType)
+	case "boolean":
+		return "bool", nil
+	case "nvarchar":
+		return "string", size("")
+	case "varchar", "shorttext":
+		return "string", size(dataType)
+	case "alphanum":
+		return "hdb.Alphanum", size(dataType)
+	case "nchar", "char":
+		return "string", typeTag(strings.ToLower(column.ColumnType()))
+	case "nclob":
+		return "hdb.NLob", nil
+	case "clob", "text":
+		return "hdb.NLob", typeTag(dataType)
+	case "blob":
+		return "hdb.Lob", nil
+	case "bintext":
+		return "hdb.Lob", typeTag(dataType)
+	case "varbinary":
+		return "[]byte", size("")
+	case "binary":
+		return "[]byte", typeTag(strings.ToLower(column.ColumnType()))
+	case "date":
+		return "hdb.Date", nil
+	case "time":
+		return "hdb.Time", nil
+	case "seconddate":
+		return "hdb.SecondDate", nil
+	case "timestamp":
+		return "time.Time", nil
+	case "real_vector":
+		return "hdb.Vector", nil
+	case "st_point":
+		return "hdb.Point", nil
+	case "st_geometry":
+		return "hdb.Geometry", nil
+	}
+	// unknown types are read as strings
+	return "string", typeTag(strings.ToLower(column.ColumnType()))
+}
+
+func isIntegerType(typ string) bool {
+	switch typ {
+	case "uint8", "int16", "int32", "int64":
+		return true
+	}
+	return false
+}
+
+// newModel maps the columns of table to fields
+func newModel(table hdb.CatalogTable, name string, fieldNames map[string]string) *model {
+	m := &model{table: table, name: name, columns: map[string]string{}, names: map[string]bool{}}
+
+	primaryKey := map[string]bool{}
+	for _, c := range table.PrimaryKey {
+		primaryKey[c] = true
+	}
+	indexTags := map[string][]string{}
+	for _, idx := range table.Indexes {
+		key := "index"
+		if idx.Unique {
+			key = "uniqueIndex"
+		}
+		for i, c := range idx.Columns {
+			tag := key + ":" + tagValue(idx.Name)
+			if len(idx.Columns) > 1 {
+				tag += ",priority:" + strconv.Itoa(i+1)
+			}
+			indexTags[c] = append(indexTags[c], tag)
+		}
+	}
+
+	for _, column := range table.Columns {
+		typ, typeTags := columnField(column)
+		fieldName := column.Name
+		if name, ok := fieldNames[column.Name]; ok {
+			fieldName = name
+		}
+		f := field{name: uniqueName(m.names, goName(fieldName)), typ: typ}
+		m.columns[column.Name] = f.name
+
+		f.tags = append(f.tags, "column:"+tagValue(column.Name))
+		f.tags = append(f.tags, typeTags...)
+		switch {
+		case primaryKey[column.Name]:
+			f.tags = append(f.tags, "primaryKey")
+			if column.Identity {
+				f.tags = append(f.tags, "autoIncrement")
+			} else if isIntegerType(typ) && len(table.PrimaryKey) == 1 {
+				// gorm creates single integer primary keys as identity columns by default
+				f.tags = append(f.tags, "autoIncrement:false")
+			}
+		case column.Nullable:
+			if nullableTypes[typ] {
+				f.typ = "*" + typ
+			} else if typ == "hdb.Decimal" {
+				f.typ = "hdb.NullDecimal"
+			}
+		default:
+			f.tags = append(f.tags, "not null")
+		}
+		if column.Identity && !primaryKey[column.Name] {
+			f.tags = append(f.tags, "autoIncrement")
+		}
+		f.tags = append(f.tags, indexTags[column.Name]...)
+		if column.DefaultValue != "" {
+			f.tags = append(f.tags, "default:"+tagValue(column.DefaultValue))
+		}
+		if column.Comment != "" {
+			f.tags = append(f.tags, "comment:"+tagValue(column.Comment))
+		}
+		m.fields = append(m.fields, f)
+	}
+	return m
+}
+
+// constraintTag returns the constraint tag of the referential actions of fk
+func constraintTag(fk hdb.CatalogForeignKey) string {
+	var actions []string
+	for _, action := range []struct{ name, rule string }{{"OnUpdate", fk.UpdateRule}, {"OnDelete", fk.DeleteRule}} {
+		if rule := strings.ToUpper(action.rule); rule != "" && rule != "RESTRICT" && rule != "NO ACTION" {
+			actions = append(actions, action.name+":"+rule)
+		}
+	}
+	if len(actions) == 0 {
+		return ""
+	}
+	return "constraint:" + strings.Join(actions, ",")
+}
+
+// addAssociations adds the fields of the foreign keys between the models
+func addAssociations(models []*model) {
+	byTable := map[string]*model{}
+	for _, m := range models {
+		byTable[qualifiedName(m.table.Schema, m.table.Name)] = m
+	}
+
+	for _, m := range models {
+		for _, fk := range m.table.ForeignKeys {
+			ref, ok := byTable[qualifiedName(fk.ReferencedSchema, fk.ReferencedTable)]
+			if !ok {
+				continue
+			}
+			foreignKey := "foreignKey:" + m.fieldNames(fk.Columns) + ";references:" + ref.fieldNames(fk.ReferencedColumns)
+
+			// CUSTOMER_ID references the Customer
+			name := ref.name
+			if len(fk.Columns) == 1 {
+				if trimmed := goName(fk.Columns[0]); strings.HasSuffix(trimmed, "ID") && len(trimmed) > 2 {
+					name = strings.TrimSuffix(trimmed, "ID")
+				}
+			}
+			tags := []string{foreignKey}
+			if tag := constraintTag(fk); tag != "" {
+				tags = append(tags, tag)
+			}
+			belongsTo := field{name: uniqueName(m.names, name), typ: "*" + ref.name, tags: tags}
+			m.fields = append(m.fields, belongsTo)
+
+			// gorm creates the constraint of the reverse association if both exist
+			reverse := field{typ: "[]" + m.name, tags: tags}
+			reverseName := inflection.Plural(m.name)
+			if m.isUnique(fk.Columns) {
+				reverse.typ, reverseName = "*"+m.name, m.name
+			}
+			if ref.names[reverseName] {
+				// e.g. OrdersBillTo for a second foreign key of orders
+				reverseName += belongsTo.name
+			}
+			reverse.name = uniqueName(ref.names, reverseName)
+			ref.fields = append(ref.fields, reverse)
+		}
+	}
+}
+
+// Generate returns the formatted source of the models of tables
+func Generate(tables []hdb.CatalogTable, config Config) ([]byte, error) {
+	pkg := config.Package
+	if pkg == "" {
+		pkg = "models"
+	}
+
+	structNames := map[string]bool{}
+	models := make([]*model, len(tables))
+	for i, t := range tables {
+		// mixed case names like OrderItems keep their words
+		name := strings.ToLower(t.Name)
+		if structName, ok := config.StructNames[t.Name]; ok {
+			name = structName
+		}
+		models[i] = newModel(t, uniqueName(structNames, goName(inflection.Singular(name))), config.FieldNames[t.Name])
+	}
+	addAssociations(models)
+
+	var body bytes.Buffer
+	imports := map[string]bool{}
+	for _, m := range models {
+		description := fmt.Sprintf("// %s is the table %s", m.name, qualifiedName(m.table.Schema, m.table.Name))
+		if m.table.Comment != "" {
+			description += ": " + strings.Join(strings.Fields(m.table.Comment), " ")
+		}
+		fmt.Fprintf(&body, "%s\ntype %s struct {\n", description, m.name)
+		for _, f := range m.fields {
+			fmt.Fprintf(&body, "\t%s %s `gorm:%s`\n", f.name, f.typ, strconv.Quote(strings.Join(f.tags, ";")))
+			switch {
+			case strings.Contains(f.typ, "hdb."):
+				imports[hdbImport] = true
+			case strings.Contains(f.typ, "time."):
+				imports["time"] = true
+			}
+		}
+		body.WriteString("}\n\n")
+
+		tableName := m.table.Name
+		if config.QualifiedTableNames {
+			tableName = qualifiedName(m.table.Schema, m.table.Name)
+		}
+		fmt.Fprintf(&body, "// TableName returns the table name of %s\nfunc (%s) TableName() string {\n\treturn %s\n}\n\n",
+			m.name, m.name, strconv.Quote(tableName))
+	}
+
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "// Code generated by hana-gormgen. DO NOT EDIT.\n\npackage %s\n\n", pkg)
+	if len(imports) > 0 {
+		src.WriteString("import (\n")
+		if imports["time"] {
+			src.WriteString("\t\"time\"\n\n")
+		}
+		if imports[hdbImport] {
+			fmt.Fprintf(&src, "\thdb %q\n", hdbImport)
+		}
+		src.WriteString(")\n\n")
+	}
+	body.WriteTo(&src)
+	return format.Source(src.Bytes())
+}
@@ -0,0 +1,122 @@
+package gormgen
+
+import (
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	hdb "gorm.io/driver/hana/hdb"
+)
+
+// regenerate the golden files with
+//
+//	go test ./hdb/gormgen -update
+var updateGolden = flag.Bool("update", false, "update golden files")
+
+var salesTables = []hdb.CatalogTable{
+	{
+		Schema:  "SALES",
+		Name:    "CUSTOMERS",
+		Comment: "customer master",
+		Columns: []hdb.CatalogColumn{
+			{Name: "ID", DataType: "INTEGER"},
+			{Name: "NAME", DataType: "NVARCHAR", Length: 100},
+			{Name: "COUNTRY", DataType: "NCHAR", Length: 2, Nullable: true},
+			{Name: "PARENT_ID", DataType: "INTEGER", Nullable: true},
+			{Name: "LOCATION", DataType: "ST_POINT", Nullable: true},
+		},
+		PrimaryKey: []string{"ID"},
+		ForeignKeys: []hdb.CatalogForeignKey{{
+			Name: "FK_CUSTOMERS_PARENT", Columns: []string{"PARENT_ID"},
+			ReferencedSchema: "SALES", ReferencedTable: "CUSTOMERS", ReferencedColumns: []string{"ID"}, DeleteRule: "SET NULL",
+		}},
+	},
+	{
+		Schema: "SALES",
+		Name:   "ORDERS",
+		Columns: []hdb.CatalogColumn{
+			{Name: "ID", DataType: "BIGINT", Identity: true},
+			{Name: "NUMBER", DataType: "ALPHANUM", Length: 10},
+			{Name: "CUSTOMER_ID", DataType: "INTEGER"},
+			{Name: "AMOUNT", DataType: "DECIMAL", Length: 15, Scale: 2, Nullable: true, DefaultValue: "0"},
+			{Name: "RATE", DataType: "REAL"},
+			{Name: "PAID", DataType: "BOOLEAN", Nullable: true},
+			{Name: "NOTE", DataType: "NCLOB", Nullable: true, Comment: "free text; not searched"},
+			{Name: "DELIVERY_DATE", DataType: "DATE", Nullable: true},
+			{Name: "CREATED_AT", DataType: "TIMESTAMP", Nullable: true},
+		},
+		PrimaryKey: []string{"ID"},
+		Indexes: []hdb.CatalogIndex{
+			{Name: "UK_ORDERS_NUMBER", Columns: []string{"NUMBER"}, Unique: true},
+			{Name: "IDX_ORDERS_CUSTOMER", Columns: []string{"CUSTOMER_ID", "CREATED_AT"}},
+		},
+		ForeignKeys: []hdb.CatalogForeignKey{{
+			Name: "FK_ORDERS_CUSTOMER", Columns: []string{"CUSTOMER_ID"},
+			ReferencedSchema: "SALES", ReferencedTable: "CUSTOMERS", ReferencedColumns: []string{"ID"}, DeleteRule: "CASCADE",
+		}},
+	},
+	{
+		Schema: "SALES",
+		Name:   "ORDER_INVOICES",
+		Columns: []hdb.CatalogColumn{
+			{Name: "ORDER_ID", DataType: "BIGINT"},
+			{Name: "DOCUMENT", DataType: "BLOB", Nullable: true},
+			{Name: "CHECKSUM", DataType: "VARBINARY", Length: 32},
+		},
+		PrimaryKey: []string{"ORDER_ID"},
+		ForeignKeys: []hdb.CatalogForeignKey{
+			{
+				Name: "FK_ORDER_INVOICES_ORDER", Columns: []string{"ORDER_ID"},
+				ReferencedSchema: "SALES", ReferencedTable: "ORDERS", ReferencedColumns: []string{"ID"},
+			},
+			{
+				Name: "FK_ORDER_INVOICES_PRODUCT", Columns: []string{"ORDER_ID"},
+				ReferencedSchema: "MASTER", ReferencedTable: "PRODUCTS", ReferencedColumns: []string{"ID"},
+			},
+		},
+	},
+}
+
+func TestGenerate(t *testing.T) {
+	src, err := Generate(salesTables, Config{Package: "sales", QualifiedTableNames: true})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	path := filepath.Join("testdata", "sales.golden")
+	if *updateGolden {
+		assert.Nil(t, ioutil.WriteFile(path, src, 0644))
+	}
+	golden, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, string(golden), string(src))
+}
+
+func TestGenerateFieldNames(t *testing.T) {
+	src, err := Generate(salesTables[:1], Config{FieldNames: map[string]map[string]string{
+		"CUSTOMERS": {"PARENT_ID": "parentCustomer_ID"},
+	}})
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Contains(t, string(src), "ParentCustomerID *int32")
+	assert.Contains(t, string(src), "foreignKey:ParentCustomerID;references:ID")
+}
+
+func TestGoName(t *testing.T) {
+	for name, expected := range map[string]string{
+		"CUSTOMER_ID": "CustomerID",
+		"customerId":  "CustomerID",
+		"createdAt":   "CreatedAt",
+		"ORDER.ITEMS": "OrderItems",
+		"API_URL":     "APIURL",
+		"2ND_ADDRESS": "X2ndAddress",
+		"__":          "X",
+		"Straße_Name": "StraßeName",
+	} {
+		assert.Equal(t, expected, goName(name), name)
+	}
+}
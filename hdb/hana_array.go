@@ -0,0 +1,164 @@
+package hdb
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// Array values are written with the ARRAY constructor, as the driver can not bind
+// arrays. The driver does not decode ARRAY result columns either, so array fields
+// are write-only: queries omit them unless they are selected explicitly and the
+// fields of queried records stay nil. The arrays are queried with MemberOf and
+// Cardinality instead.
+//
+//	type Post struct {
+//		Tags    hdb.StringArray `gorm:"size:50"`
+//		Ratings hdb.IntArray
+//	}
+//
+//	db.Where(hdb.MemberOf("go", "tags")).Where("? > ?", hdb.Cardinality("ratings"), 2).Find(&posts)
+
+// IntArray is a write-only BIGINT ARRAY model field
+type IntArray []int64
+
+// GormDataType gorm common data type
+func (IntArray) GormDataType() string {
+	return "array"
+}
+
+// GormDBDataType gorm db data type, use the type tag for other element types like `type:integer array`
+func (IntArray) GormDBDataType(db *gorm.DB, field *schema.Field) string {
+	if dataType, ok := field.TagSettings["TYPE"]; ok {
+		return dataType
+	}
+	return "bigint array"
+}
+
+// GormValue writes the array with the ARRAY constructor
+func (a IntArray) GormValue(ctx context.Context, db *gorm.DB) clause.Expr {
+	return arrayExpr(reflect.ValueOf(a))
+}
+
+// QueryClauses omits the array column from queries
+func (IntArray) QueryClauses(f *schema.Field) []clause.Interface {
+	return []clause.Interface{arrayOmitClause{field: f}}
+}
+
+// StringArray is a write-only NVARCHAR ARRAY model field, the element length is
+// set by the size tag
+type StringArray []string
+
+// GormDataType gorm common data type
+func (StringArray) GormDataType() string {
+	return "array"
+}
+
+// GormDBDataType gorm db data type
+func (StringArray) GormDBDataType(db *gorm.DB, field *schema.Field) string {
+	if dataType, ok := field.TagSettings["TYPE"]; ok {
+		return dataType
+	}
+	size := field.Size
+	if size <= 0 {
+		size = 255
+	}
+	if size > maxVarLength {
+		// lob types are no valid element types
+		size = maxVarLength
+	}
+	return fmt.Sprintf("nvarchar(%d) array", size)
+}
+
+// GormValue writes the array with the ARRAY constructor
+func (a StringArray) GormValue(ctx context.Context, db *gorm.DB) clause.Expr {
+	return arrayExpr(reflect.ValueOf(a))
+}
+
+// QueryClauses omits the array column from queries
+func (StringArray) QueryClauses(f *schema.Field) []clause.Interface {
+	return []clause.Interface{arrayOmitClause{field: f}}
+}
+
+// arrayOmitClause omits an array field from queries without selected columns,
+// as the driver can not decode ARRAY result columns
+type arrayOmitClause struct {
+	field *schema.Field
+}
+
+func (c arrayOmitClause) Name() string {
+	return ""
+}
+
+func (c arrayOmitClause) Build(clause.Builder) {
+}
+
+func (c arrayOmitClause) MergeClause(*clause.Clause) {
+}
+
+func (c arrayOmitClause) ModifyStatement(stmt *gorm.Statement) {
+	if len(stmt.Selects) > 0 {
+		return
+	}
+	for _, omit := range stmt.Omits {
+		if omit == c.field.DBName || omit == c.field.Name {
+			return
+		}
+	}
+	stmt.Omits = append(stmt.Omits, c.field.DBName)
+}
+
+// GenericArray binds slices of any element type
+type GenericArray struct {
+	ptr interface{}
+}
+
+// Array wraps the slice pointed to by ptr for use as query argument
+//
+//	db.Model(&post).Update("scores", hdb.Array(&scores))
+func Array(ptr interface{}) GenericArray {
+	return GenericArray{ptr: ptr}
+}
+
+// GormValue writes the array with the ARRAY constructor
+func (a GenericArray) GormValue(ctx context.Context, db *gorm.DB) clause.Expr {
+	rv := reflect.ValueOf(a.ptr)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Slice {
+		db.AddError(fmt.Errorf("array: invalid value %T, pointer to slice expected", a.ptr))
+		return clause.Expr{SQL: "NULL"}
+	}
+	return arrayExpr(rv.Elem())
+}
+
+// MemberOf is true if value is an element of the array column
+func MemberOf(value interface{}, column string) clause.Expression {
+	return clause.Expr{SQL: "? MEMBER OF ?", Vars: []interface{}{value, clause.Column{Name: column}}}
+}
+
+// Cardinality is the number of elements of the array column
+func Cardinality(column string) clause.Expression {
+	return clause.Expr{SQL: "CARDINALITY(?)", Vars: []interface{}{clause.Column{Name: column}}}
+}
+
+// arrayExpr returns the ARRAY constructor of the elements of slice, NULL for a nil slice
+func arrayExpr(slice reflect.Value) clause.Expr {
+	if slice.IsNil() {
+		return clause.Expr{SQL: "NULL"}
+	}
+
+	vars := make([]interface{}, slice.Len())
+	for i := range vars {
+		vars[i] = slice.Index(i).Interface()
+		if b, ok := vars[i].([]byte); ok {
+			vars[i] = bytesValue(b)
+		}
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(vars)), ",")
+	return clause.Expr{SQL: "ARRAY(" + placeholders + ")", Vars: vars}
+}
@@ -0,0 +1,88 @@
+package hdb
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"gorm.io/driver/hana/hdb/hanatest"
+)
+
+func TestArray(t *testing.T) {
+	type Post struct {
+		ID      uint
+		Tags    StringArray `gorm:"size:50"`
+		Labels  StringArray
+		Ratings IntArray
+		Votes   IntArray `gorm:"type:integer array"`
+	}
+
+	db := newDryRunDB(t)
+	stmt := db.Model(&Post{}).Statement
+	assert.Nil(t, stmt.Parse(&Post{}))
+	m := db.Migrator().(Migrator)
+	for name, dataType := range map[string]string{
+		"Tags":    "nvarchar(50) array",
+		"Labels":  "nvarchar(255) array",
+		"Ratings": "bigint array",
+		"Votes":   "integer array",
+	} {
+		assert.Equal(t, dataType, m.FullDataTypeOf(stmt.Schema.LookUpField(name)).SQL, name)
+	}
+
+	create := db.Create(&Post{Tags: StringArray{"go", "hana"}, Ratings: IntArray{}, Votes: IntArray{3}}).Statement
+	assert.Equal(t, `INSERT INTO "posts" ("tags","labels","ratings","votes") VALUES (ARRAY(?,?),NULL,ARRAY(),ARRAY(?))`, create.SQL.String())
+	assert.Equal(t, []interface{}{"go", "hana", int64(3)}, create.Vars)
+
+	query := db.Where(MemberOf("go", "tags")).Where("? > ?", Cardinality("ratings"), 2).Find(&[]Post{}).Statement
+	// the driver can not decode arrays, they are omitted unless selected
+	assert.Equal(t, `SELECT "posts"."id" FROM "posts" WHERE ? MEMBER OF "tags" AND CARDINALITY("ratings") > ?`, query.SQL.String())
+	assert.Equal(t, []interface{}{"go", 2}, query.Vars)
+	query = db.Select("id", "tags").Find(&[]Post{}).Statement
+	assert.Equal(t, `SELECT "id","tags" FROM "posts"`, query.SQL.String())
+	query = db.Model(&Post{}).Count(new(int64)).Statement
+	assert.Equal(t, `SELECT count(*) FROM "posts"`, query.SQL.String())
+
+	checksums := [][]byte{{1, 2}, {3}}
+	update := db.Model(&Post{ID: 1}).Update("checksums", Array(&checksums)).Statement
+	assert.Equal(t, `UPDATE "posts" SET "checksums"=ARRAY(?,?) WHERE "id" = ?`, update.SQL.String())
+	assert.NotNil(t, db.Model(&Post{ID: 1}).Update("checksums", Array(checksums)).Error)
+
+	// arrays are write-only, they are no scan destinations
+	for _, value := range []interface{}{new(StringArray), new(IntArray), Array(&checksums)} {
+		_, ok := value.(sql.Scanner)
+		assert.False(t, ok, "%T", value)
+	}
+}
+
+func TestArrayQuery(t *testing.T) {
+	type Post struct {
+		ID   uint
+		Tags StringArray
+	}
+
+	script := hanatest.NewScript()
+	script.Expect(`SELECT "posts"."id" FROM "posts" WHERE "posts"."id" = ?`).
+		WithArgs(1).
+		ReturnsColumns(hanatest.Column{Name: "id", Type: hanatest.BigInt}).
+		ReturnsRow(1)
+
+	srv := hanatest.NewServer(script)
+	defer srv.Close()
+	db, err := gorm.Open(Open(srv.DSN), &gorm.Config{Logger: logger.Discard})
+	if !assert.Nil(t, err) {
+		return
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		defer sqlDB.Close()
+	}
+
+	// the tags of queried posts stay nil
+	var post Post
+	assert.Nil(t, db.Where(&Post{ID: 1}).Find(&post).Error)
+	assert.Equal(t, Post{ID: 1}, post)
+	assert.Nil(t, script.ExpectationsWereMet())
+}
@@ -0,0 +1,591 @@
+package hdb
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+	"gorm.io/gorm/migrator"
+)
+
+// CatalogTable is a table as described by the HANA catalog views
+type CatalogTable struct {
+	Schema  string          `json:"schema" yaml:"schema"`
+	Name    string          `json:"name" yaml:"name"`
+	Comment string          `json:"comment,omitempty" yaml:"comment,omitempty"`
+	Columns []CatalogColumn `json:"columns" yaml:"columns"`
+	// PrimaryKey are the primary key columns in key order
+	PrimaryKey  []string            `json:"primaryKey,omitempty" yaml:"primaryKey,omitempty"`
+	Indexes     []CatalogIndex      `json:"indexes,omitempty" yaml:"indexes,omitempty"`
+	ForeignKeys []CatalogForeignKey `json:"foreignKeys,omitempty" yaml:"foreignKeys,omitempty"`
+	Checks      []CatalogCheck      `json:"checks,omitempty" yaml:"checks,omitempty"`
+}
+
+// CatalogColumn is a table column as listed by SYS.TABLE_COLUMNS
+type CatalogColumn struct {
+	Name string `json:"name" yaml:"name"`
+	// DataType is the data type name like NVARCHAR or DECIMAL
+	DataType string `json:"dataType" yaml:"dataType"`
+	// Length is the length of character and binary types or the precision
+	// of decimals, it is 0 for floating point decimals and types without length
+	Length       int    `json:"length,omitempty" yaml:"length,omitempty"`
+	Scale        int    `json:"scale,omitempty" yaml:"scale,omitempty"`
+	Nullable     bool   `json:"nullable,omitempty" yaml:"nullable,omitempty"`
+	DefaultValue string `json:"defaultValue,omitempty" yaml:"defaultValue,omitempty"`
+	Comment      string `json:"comment,omitempty" yaml:"comment,omitempty"`
+	// Identity columns are generated by default or always as identity
+	Identity bool `json:"identity,omitempty" yaml:"identity,omitempty"`
+}
+
+// ColumnType returns the column type like NVARCHAR(100) or DECIMAL(15, 2)
+func (c CatalogColumn) ColumnType() string {
+	var length, scale sql.NullInt64
+	if c.Length > 0 {
+		length = sql.NullInt64{Int64: int64(c.Length), Valid: true}
+		scale = sql.NullInt64{Int64: int64(c.Scale), Valid: true}
+	}
+	return columnTypeOf(c.DataType, length, scale)
+}
+
+// CatalogIndex is an index or unique constraint of a table
+type CatalogIndex struct {
+	Name    string   `json:"name" yaml:"name"`
+	Columns []string `json:"columns" yaml:"columns"`
+	Unique  bool     `json:"unique,omitempty" yaml:"unique,omitempty"`
+}
+
+// CatalogForeignKey is a foreign key constraint of a table
+type CatalogForeignKey struct {
+	Name              string   `json:"name" yaml:"name"`
+	Columns           []string `json:"columns" yaml:"columns"`
+	ReferencedSchema  string   `json:"referencedSchema" yaml:"referencedSchema"`
+	ReferencedTable   string   `json:"referencedTable" yaml:"referencedTable"`
+	ReferencedColumns []string `json:"referencedColumns" yaml:"referencedColumns"`
+	// DeleteRule and UpdateRule are the referential actions like CASCADE or RESTRICT
+	DeleteRule string `json:"deleteRule,omitempty" yaml:"deleteRule,omitempty"`
+	UpdateRule string `json:"updateRule,omitempty" yaml:"updateRule,omitempty"`
+}
+
+// CatalogCheck is a check constraint of a table
+type CatalogCheck struct {
+	Name      string `json:"name" yaml:"name"`
+	Condition string `json:"condition" yaml:"condition"`
+}
+
+// CatalogSequence is a sequence as listed by SYS.SEQUENCES
+type CatalogSequence struct {
+	Name       string `json:"name" yaml:"name"`
+	StartValue int64  `json:"startValue" yaml:"startValue"`
+	MinValue   int64  `json:"minValue" yaml:"minValue"`
+	MaxValue   int64  `json:"maxValue" yaml:"maxValue"`
+	Increment  int64  `json:"increment" yaml:"increment"`
+	Cycle      bool   `json:"cycle,omitempty" yaml:"cycle,omitempty"`
+}
+
+// CatalogView is a view as listed by SYS.VIEWS
+type CatalogView struct {
+	Name string `json:"name" yaml:"name"`
+	// Definition is the query of the view
+	Definition string `json:"definition" yaml:"definition"`
+	Comment    string `json:"comment,omitempty" yaml:"comment,omitempty"`
+}
+
+// Snapshot is the catalog of a schema, which is used by tools without a live connection
+type Snapshot struct {
+	Schema    string            `json:"schema" yaml:"schema"`
+	Tables    []CatalogTable    `json:"tables" yaml:"tables"`
+	Sequences []CatalogSequence `json:"sequences,omitempty" yaml:"sequences,omitempty"`
+	Views     []CatalogView     `json:"views,omitempty" yaml:"views,omitempty"`
+}
+
+// isYAML reports whether path is a YAML file, other files are JSON
+func isYAML(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// LoadSnapshot reads a snapshot file written by Snapshot.Save
+func LoadSnapshot(path string) (*Snapshot, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := &Snapshot{}
+	if isYAML(path) {
+		err = yaml.Unmarshal(b, snapshot)
+	} else {
+		err = json.Unmarshal(b, snapshot)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid snapshot %s: %w", path, err)
+	}
+	return snapshot, nil
+}
+
+// Save writes the snapshot as YAML for .yaml and .yml files and as indented JSON otherwise
+func (s *Snapshot) Save(path string) error {
+	var buffer bytes.Buffer
+	if err := s.Encode(&buffer, isYAML(path)); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, buffer.Bytes(), 0644)
+}
+
+// Encode writes the snapshot as indented JSON or as YAML
+func (s *Snapshot) Encode(w io.Writer, asYAML bool) error {
+	if asYAML {
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(s); err != nil {
+			return err
+		}
+		return encoder.Close()
+	}
+
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
+
+// Table returns the table of the snapshot named name
+func (s *Snapshot) Table(name string) (CatalogTable, bool) {
+	return s.lookup(s.Schema, name)
+}
+
+// Sequence returns the sequence of the snapshot named name
+func (s *Snapshot) Sequence(name string) (CatalogSequence, bool) {
+	for _, seq := range s.Sequences {
+		if seq.Name == name {
+			return seq, true
+		}
+	}
+	return CatalogSequence{}, false
+}
+
+// View returns the view of the snapshot named name
+func (s *Snapshot) View(name string) (CatalogView, bool) {
+	for _, v := range s.Views {
+		if v.Name == name {
+			return v, true
+		}
+	}
+	return CatalogView{}, false
+}
+
+// lookup returns the table of schemaName, tables without schema belong to the snapshot schema
+func (s *Snapshot) lookup(schemaName, tableName string) (CatalogTable, bool) {
+	for _, t := range s.Tables {
+		tableSchema := t.Schema
+		if tableSchema == "" {
+			tableSchema = s.Schema
+		}
+		if tableSchema == schemaName && t.Name == tableName {
+			return t, true
+		}
+	}
+	return CatalogTable{}, false
+}
+
+func (t CatalogTable) column(name string) *CatalogColumn {
+	for i := range t.Columns {
+		if t.Columns[i].Name == name {
+			return &t.Columns[i]
+		}
+	}
+	return nil
+}
+
+// hasConstraint reports whether the table has a unique, check or foreign key constraint name
+func (t CatalogTable) hasConstraint(name string) bool {
+	for _, idx := range t.Indexes {
+		if idx.Unique && idx.Name == name {
+			return true
+		}
+	}
+	for _, chk := range t.Checks {
+		if chk.Name == name {
+			return true
+		}
+	}
+	for _, fk := range t.ForeignKeys {
+		if fk.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// columnTypes returns the column types like Migrator.ColumnTypes reads them from the catalog
+func (t CatalogTable) columnTypes() []gorm.ColumnType {
+	columnTypes := make([]gorm.ColumnType, 0, len(t.Columns))
+	for _, c := range t.Columns {
+		column := migrator.ColumnType{
+			SQLColumnType:     &sql.ColumnType{},
+			NameValue:         sql.NullString{String: c.Name, Valid: true},
+			DataTypeValue:     sql.NullString{String: c.DataType, Valid: true},
+			ColumnTypeValue:   sql.NullString{String: c.ColumnType(), Valid: true},
+			NullableValue:     sql.NullBool{Bool: c.Nullable, Valid: true},
+			DefaultValueValue: sql.NullString{String: c.DefaultValue, Valid: c.DefaultValue != ""},
+			CommentValue:      sql.NullString{String: c.Comment, Valid: c.Comment != ""},
+		}
+		if hasLength(c.DataType) {
+			column.LengthValue = sql.NullInt64{Int64: int64(c.Length), Valid: true}
+		}
+		if isDecimalDataType(c.DataType) && c.Length > 0 {
+			column.DecimalSizeValue = sql.NullInt64{Int64: int64(c.Length), Valid: true}
+			column.ScaleValue = sql.NullInt64{Int64: int64(c.Scale), Valid: true}
+		}
+
+		// like the catalog join, only columns with constraints report them
+		primaryKey := containsString(t.PrimaryKey, c.Name)
+		unique := false
+		for _, idx := range t.Indexes {
+			unique = unique || (idx.Unique && len(idx.Columns) == 1 && idx.Columns[0] == c.Name)
+		}
+		if primaryKey || unique {
+			column.PrimaryKeyValue = sql.NullBool{Bool: primaryKey, Valid: true}
+			column.UniqueValue = sql.NullBool{Bool: unique, Valid: true}
+		}
+		columnTypes = append(columnTypes, column)
+	}
+	return columnTypes
+}
+
+// ErrNoConnection is returned for statements of databases opened with a
+// catalog snapshot but without connection
+var ErrNoConnection = errors.New("hdb: no database connection")
+
+// noConnector is the connector of databases opened with a catalog snapshot
+// only, its connections answer pings but no statements
+type noConnector struct{}
+
+func (noConnector) Connect(context.Context) (driver.Conn, error) { return noConn{}, nil }
+func (c noConnector) Driver() driver.Driver                      { return c }
+func (noConnector) Open(string) (driver.Conn, error)             { return noConn{}, nil }
+
+type noConn struct{}
+
+func (noConn) Prepare(string) (driver.Stmt, error) { return nil, ErrNoConnection }
+func (noConn) Begin() (driver.Tx, error)           { return nil, ErrNoConnection }
+func (noConn) Close() error                        { return nil }
+func (noConn) Ping(context.Context) error          { return nil }
+
+// Snapshot reads the tables of schemaName from the catalog, all tables,
+// sequences and views if no tableNames are given. An empty schemaName is the
+// current schema.
+func (m Migrator) Snapshot(schemaName string, tableNames ...string) (*Snapshot, error) {
+	if schemaName == "" {
+		schemaName = m.DB.Migrator().CurrentDatabase()
+	}
+	tables, err := m.CatalogTables(schemaName, tableNames...)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := &Snapshot{Schema: schemaName, Tables: tables}
+	if len(tableNames) > 0 {
+		return snapshot, nil
+	}
+
+	if snapshot.Sequences, err = m.CatalogSequences(schemaName); err != nil {
+		return nil, err
+	}
+	if snapshot.Views, err = m.CatalogViews(schemaName); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// CatalogSequences reads the sequences of schemaName from SYS.SEQUENCES
+func (m Migrator) CatalogSequences(schemaName string) ([]CatalogSequence, error) {
+	rows, err := m.DB.Raw(
+		"SELECT SEQUENCE_NAME, START_NUMBER, MIN_VALUE, MAX_VALUE, INCREMENT_BY, IS_CYCLED "+
+			"FROM SYS.SEQUENCES WHERE SCHEMA_NAME = ? ORDER BY SEQUENCE_NAME", schemaName,
+	).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sequences []CatalogSequence
+	for rows.Next() {
+		var (
+			seq    CatalogSequence
+			cycled string
+		)
+		if err := rows.Scan(&seq.Name, &seq.StartValue, &seq.MinValue, &seq.MaxValue, &seq.Increment, &cycled); err != nil {
+			return nil, err
+		}
+		seq.Cycle = cycled == "TRUE"
+		sequences = append(sequences, seq)
+	}
+	return sequences, rows.Err()
+}
+
+// catalogLob scans a NCLOB catalog column like SYS.VIEWS.DEFINITION or
+// SYS.CONSTRAINTS.CHECK_CONDITION,
+// which the driver returns as lob instead of string
+type catalogLob struct {
+	String string
+	Valid  bool
+}
+
+// Scan implements the database/sql/Scanner interface
+func (c *catalogLob) Scan(src interface{}) error {
+	*c = catalogLob{}
+	if src == nil {
+		return nil
+	}
+
+	var builder strings.Builder
+	lob := Lob{Writer: &builder}
+	if err := lob.Scan(src); err != nil {
+		return err
+	}
+	c.String, c.Valid = builder.String(), true
+	return nil
+}
+
+// CatalogViews reads the views of schemaName from SYS.VIEWS
+func (m Migrator) CatalogViews(schemaName string) ([]CatalogView, error) {
+	rows, err := m.DB.Raw(
+		"SELECT VIEW_NAME, DEFINITION, COMMENTS FROM SYS.VIEWS WHERE SCHEMA_NAME = ? ORDER BY VIEW_NAME", schemaName,
+	).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var views []CatalogView
+	for rows.Next() {
+		var (
+			view       CatalogView
+			definition catalogLob
+			comment    sql.NullString
+		)
+		if err := rows.Scan(&view.Name, &definition, &comment); err != nil {
+			return nil, err
+		}
+		view.Definition, view.Comment = definition.String, comment.String
+		views = append(views, view)
+	}
+	return views, rows.Err()
+}
+
+// CatalogTables reads the tables of schemaName from SYS.TABLES, SYS.TABLE_COLUMNS,
+// SYS.CONSTRAINTS, SYS.INDEX_COLUMNS and SYS.REFERENTIAL_CONSTRAINTS,
+// all tables of the schema if no tableNames are given
+func (m Migrator) CatalogTables(schemaName string, tableNames ...string) ([]CatalogTable, error) {
+	rows, err := m.DB.Raw(
+		"SELECT TABLE_NAME, COMMENTS FROM SYS.TABLES WHERE SCHEMA_NAME = ? ORDER BY TABLE_NAME", schemaName,
+	).Rows()
+	if err != nil {
+		return nil, err
+	}
+
+	var tables []CatalogTable
+	for rows.Next() {
+		var (
+			name    string
+			comment sql.NullString
+		)
+		if err := rows.Scan(&name, &comment); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if len(tableNames) == 0 || containsString(tableNames, name) {
+			tables = append(tables, CatalogTable{Schema: schemaName, Name: name, Comment: comment.String})
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, name := range tableNames {
+		found := false
+		for _, t := range tables {
+			found = found || t.Name == name
+		}
+		if !found {
+			return nil, fmt.Errorf("table %s.%s not found", schemaName, name)
+		}
+	}
+
+	for i := range tables {
+		if err := m.readCatalogTable(&tables[i]); err != nil {
+			return nil, err
+		}
+	}
+	return tables, nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// readCatalogTable reads the columns, keys and indexes of table
+func (m Migrator) readCatalogTable(table *CatalogTable) error {
+	rows, err := m.DB.Raw(
+		"SELECT COLUMN_NAME, DATA_TYPE_NAME, LENGTH, SCALE, IS_NULLABLE, DEFAULT_VALUE, COMMENTS, GENERATION_TYPE "+
+			"FROM SYS.TABLE_COLUMNS WHERE SCHEMA_NAME = ? AND TABLE_NAME = ? ORDER BY POSITION",
+		table.Schema, table.Name,
+	).Rows()
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var (
+			column                                CatalogColumn
+			length, scale                         sql.NullInt64
+			nullable                              string
+			defaultValue, comment, generationType sql.NullString
+		)
+		if err := rows.Scan(&column.Name, &column.DataType, &length, &scale, &nullable, &defaultValue, &comment, &generationType); err != nil {
+			rows.Close()
+			return err
+		}
+		// decimals without scale are floating point decimals
+		if hasLength(column.DataType) || (isDecimalDataType(column.DataType) && scale.Valid) {
+			column.Length, column.Scale = int(length.Int64), int(scale.Int64)
+		}
+		column.Nullable = nullable == "TRUE"
+		column.DefaultValue = defaultValue.String
+		column.Comment = comment.String
+		column.Identity = strings.HasSuffix(generationType.String, "AS IDENTITY")
+		table.Columns = append(table.Columns, column)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// unique constraints are backed by indexes of the same name
+	rows, err = m.DB.Raw(
+		"SELECT CONSTRAINT_NAME, COLUMN_NAME, IS_PRIMARY_KEY, CHECK_CONDITION FROM SYS.CONSTRAINTS "+
+			"WHERE SCHEMA_NAME = ? AND TABLE_NAME = ? ORDER BY CONSTRAINT_NAME, POSITION",
+		table.Schema, table.Name,
+	).Rows()
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var (
+			name, primaryKey string
+			column           sql.NullString
+			checkCondition   catalogLob
+		)
+		if err := rows.Scan(&name, &column, &primaryKey, &checkCondition); err != nil {
+			rows.Close()
+			return err
+		}
+		switch {
+		case checkCondition.Valid:
+			// check constraints are listed for each of their columns
+			if n := len(table.Checks); n == 0 || table.Checks[n-1].Name != name {
+				table.Checks = append(table.Checks, CatalogCheck{Name: name, Condition: checkCondition.String})
+			}
+		case primaryKey == "TRUE":
+			table.PrimaryKey = append(table.PrimaryKey, column.String)
+		default:
+			table.Indexes = appendIndexColumn(table.Indexes, name, column.String, true)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	rows, err = m.DB.Raw(
+		"SELECT INDEX_NAME, COLUMN_NAME, CONSTRAINT FROM SYS.INDEX_COLUMNS "+
+			"WHERE SCHEMA_NAME = ? AND TABLE_NAME = ? ORDER BY INDEX_NAME, POSITION",
+		table.Schema, table.Name,
+	).Rows()
+	if err != nil {
+		return err
+	}
+	constraints := len(table.Indexes)
+	for rows.Next() {
+		var (
+			name, column string
+			constraint   sql.NullString
+		)
+		if err := rows.Scan(&name, &column, &constraint); err != nil {
+			rows.Close()
+			return err
+		}
+		if constraint.String == "PRIMARY KEY" || indexOf(table.Indexes[:constraints], name) >= 0 {
+			continue
+		}
+		table.Indexes = appendIndexColumn(table.Indexes, name, column, strings.HasSuffix(constraint.String, "UNIQUE"))
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	rows, err = m.DB.Raw(
+		"SELECT CONSTRAINT_NAME, COLUMN_NAME, REFERENCED_SCHEMA_NAME, REFERENCED_TABLE_NAME, REFERENCED_COLUMN_NAME, "+
+			"DELETE_RULE, UPDATE_RULE FROM SYS.REFERENTIAL_CONSTRAINTS "+
+			"WHERE SCHEMA_NAME = ? AND TABLE_NAME = ? ORDER BY CONSTRAINT_NAME, POSITION",
+		table.Schema, table.Name,
+	).Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			fk                     CatalogForeignKey
+			column, refColumn      string
+			deleteRule, updateRule sql.NullString
+		)
+		if err := rows.Scan(&fk.Name, &column, &fk.ReferencedSchema, &fk.ReferencedTable, &refColumn, &deleteRule, &updateRule); err != nil {
+			return err
+		}
+		if n := len(table.ForeignKeys); n > 0 && table.ForeignKeys[n-1].Name == fk.Name {
+			table.ForeignKeys[n-1].Columns = append(table.ForeignKeys[n-1].Columns, column)
+			table.ForeignKeys[n-1].ReferencedColumns = append(table.ForeignKeys[n-1].ReferencedColumns, refColumn)
+			continue
+		}
+		fk.Columns, fk.ReferencedColumns = []string{column}, []string{refColumn}
+		fk.DeleteRule, fk.UpdateRule = deleteRule.String, updateRule.String
+		table.ForeignKeys = append(table.ForeignKeys, fk)
+	}
+	return rows.Err()
+}
+
+func indexOf(indexes []CatalogIndex, name string) int {
+	for i, idx := range indexes {
+		if idx.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// appendIndexColumn adds column to the index name, rows are ordered by index name
+func appendIndexColumn(indexes []CatalogIndex, name, column string, unique bool) []CatalogIndex {
+	if n := len(indexes); n > 0 && indexes[n-1].Name == name {
+		indexes[n-1].Columns = append(indexes[n-1].Columns, column)
+		return indexes
+	}
+	return append(indexes, CatalogIndex{Name: name, Columns: []string{column}, Unique: unique})
+}
@@ -0,0 +1,171 @@
+package hdb
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"gorm.io/driver/hana/hdb/hanatest"
+)
+
+func newCatalogDB(t *testing.T, tables ...hanatest.Table) *gorm.DB {
+	db, err := gorm.Open(New(Config{Connector: hanatest.NewCatalog(tables...)}), &gorm.Config{Logger: logger.Discard})
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	return db
+}
+
+var catalogTables = []hanatest.Table{
+	{
+		Name:    "ORDERS",
+		Comment: "sales orders",
+		Columns: []hanatest.TableColumn{
+			{Name: "ID", DataType: "BIGINT", Length: 19, PrimaryKey: true, Identity: true},
+			{Name: "NUMBER", DataType: "ALPHANUM", Length: 10, Unique: true},
+			{Name: "CUSTOMER_ID", DataType: "INTEGER", Length: 10},
+			{Name: "AMOUNT", DataType: "DECIMAL", Length: 15, Scale: 2, Nullable: true, DefaultValue: "0"},
+			{Name: "CREATED_AT", DataType: "TIMESTAMP", Length: 27, Nullable: true, Comment: "creation time"},
+		},
+		Indexes: []hanatest.Index{{Name: "IDX_ORDERS_CUSTOMER", Columns: []string{"CUSTOMER_ID", "CREATED_AT"}}},
+		Checks:  []hanatest.Check{{Name: "CHK_ORDERS_AMOUNT", Condition: "AMOUNT >= 0"}},
+		ForeignKeys: []hanatest.ForeignKey{{
+			Name: "FK_ORDERS_CUSTOMER", Columns: []string{"CUSTOMER_ID"},
+			ReferencedTable: "CUSTOMERS", ReferencedColumns: []string{"ID"}, DeleteRule: "CASCADE",
+		}},
+	},
+	{
+		Name:    "CUSTOMERS",
+		Columns: []hanatest.TableColumn{{Name: "ID", DataType: "INTEGER", Length: 10, PrimaryKey: true}},
+	},
+	{Schema: "OTHER", Name: "ITEMS"},
+}
+
+func TestCatalogTables(t *testing.T) {
+	db := newCatalogDB(t, catalogTables...)
+
+	tables, err := db.Migrator().(Migrator).CatalogTables(hanatest.DefaultSchema)
+	assert.Nil(t, err)
+	if !assert.Len(t, tables, 2) {
+		return
+	}
+	assert.Equal(t, "CUSTOMERS", tables[0].Name)
+	assert.Equal(t, []string{"ID"}, tables[0].PrimaryKey)
+
+	orders := tables[1]
+	assert.Equal(t, hanatest.DefaultSchema, orders.Schema)
+	assert.Equal(t, "sales orders", orders.Comment)
+	assert.Equal(t, []string{"ID"}, orders.PrimaryKey)
+	assert.Equal(t, []CatalogColumn{
+		{Name: "ID", DataType: "BIGINT", Identity: true},
+		{Name: "NUMBER", DataType: "ALPHANUM", Length: 10},
+		{Name: "CUSTOMER_ID", DataType: "INTEGER"},
+		{Name: "AMOUNT", DataType: "DECIMAL", Length: 15, Scale: 2, Nullable: true, DefaultValue: "0"},
+		{Name: "CREATED_AT", DataType: "TIMESTAMP", Nullable: true, Comment: "creation time"},
+	}, orders.Columns)
+	assert.Equal(t, []CatalogIndex{
+		{Name: "UK_ORDERS_NUMBER", Columns: []string{"NUMBER"}, Unique: true},
+		{Name: "IDX_ORDERS_CUSTOMER", Columns: []string{"CUSTOMER_ID", "CREATED_AT"}},
+	}, orders.Indexes)
+	assert.Equal(t, []CatalogForeignKey{{
+		Name: "FK_ORDERS_CUSTOMER", Columns: []string{"CUSTOMER_ID"},
+		ReferencedSchema: hanatest.DefaultSchema, ReferencedTable: "CUSTOMERS", ReferencedColumns: []string{"ID"},
+		DeleteRule: "CASCADE", UpdateRule: "RESTRICT",
+	}}, orders.ForeignKeys)
+	assert.Equal(t, []CatalogCheck{{Name: "CHK_ORDERS_AMOUNT", Condition: "AMOUNT >= 0"}}, orders.Checks)
+	assert.Equal(t, "DECIMAL(15, 2)", orders.Columns[3].ColumnType())
+
+	_, err = db.Migrator().(Migrator).CatalogTables(hanatest.DefaultSchema, "ITEMS")
+	assert.NotNil(t, err)
+}
+
+func TestSnapshotSaveLoad(t *testing.T) {
+	db := newCatalogDB(t, catalogTables...)
+
+	snapshot, err := db.Migrator().(Migrator).Snapshot("", "ORDERS")
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, hanatest.DefaultSchema, snapshot.Schema)
+
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	assert.Nil(t, snapshot.Save(path))
+	loaded, err := LoadSnapshot(path)
+	assert.Nil(t, err)
+	assert.Equal(t, snapshot, loaded)
+
+	_, ok := loaded.Table("ORDERS")
+	assert.True(t, ok)
+	_, ok = loaded.Table("CUSTOMERS")
+	assert.False(t, ok)
+}
+
+func TestLoadSnapshotInvalid(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"snapshot.json": "{\"tables\": [",
+		// panicked in yaml.v3 before v3.0.1, CVE-2022-28948
+		"snapshot.yaml": "0: [:!00 \xef",
+	} {
+		path := filepath.Join(dir, name)
+		assert.Nil(t, ioutil.WriteFile(path, []byte(content), 0644))
+		_, err := LoadSnapshot(path)
+		if assert.NotNil(t, err, name) {
+			assert.Contains(t, err.Error(), "invalid snapshot "+path)
+		}
+	}
+}
+
+func TestSnapshotSequencesViews(t *testing.T) {
+	catalog := hanatest.NewCatalog(catalogTables...)
+	catalog.AddSequence(hanatest.Sequence{Name: "ORDER_NUMBERS", StartValue: 1000, MinValue: 1, MaxValue: 999999, Increment: 1, Cycle: true})
+	catalog.AddSequence(hanatest.Sequence{Schema: "OTHER", Name: "ITEM_NUMBERS"})
+	catalog.AddView(hanatest.View{Name: "OPEN_ORDERS", Definition: "SELECT * FROM ORDERS WHERE AMOUNT > 0", Comment: "unpaid"})
+	db, err := gorm.Open(New(Config{Connector: catalog}), &gorm.Config{Logger: logger.Discard})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	snapshot, err := db.Migrator().(Migrator).Snapshot("")
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Len(t, snapshot.Tables, 2)
+	assert.Equal(t, []CatalogSequence{
+		{Name: "ORDER_NUMBERS", StartValue: 1000, MinValue: 1, MaxValue: 999999, Increment: 1, Cycle: true},
+	}, snapshot.Sequences)
+	assert.Equal(t, []CatalogView{
+		{Name: "OPEN_ORDERS", Definition: "SELECT * FROM ORDERS WHERE AMOUNT > 0", Comment: "unpaid"},
+	}, snapshot.Views)
+
+	// YAML snapshots answer the lookups of the Migrator offline
+	path := filepath.Join(t.TempDir(), "snapshot.yaml")
+	assert.Nil(t, snapshot.Save(path))
+	loaded, err := LoadSnapshot(path)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, snapshot, loaded)
+	_, ok := loaded.View("OPEN_ORDERS")
+	assert.True(t, ok)
+	_, ok = loaded.Sequence("ORDER_NUMBERS")
+	assert.True(t, ok)
+
+	offline, err := gorm.Open(New(Config{CatalogSnapshot: loaded}), &gorm.Config{Logger: logger.Discard})
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.True(t, offline.Migrator().HasTable("ORDERS"))
+	assert.True(t, offline.Migrator().HasColumn("ORDERS", "AMOUNT"))
+	assert.False(t, offline.Migrator().HasTable("ITEMS"))
+	columnTypes, err := offline.Migrator().ColumnTypes("ORDERS")
+	assert.Nil(t, err)
+	if assert.Len(t, columnTypes, 5) {
+		columnType, _ := columnTypes[3].ColumnType()
+		assert.Equal(t, "DECIMAL(15, 2)", columnType)
+	}
+}
@@ -0,0 +1,205 @@
+package hdb
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/SAP/go-hdb/driver/unicode/cesu8"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// The driver transfers strings as CESU-8, which encodes characters outside of the
+// basic multilingual plane (e.g. emoji) as surrogate pairs. HANA counts both
+// surrogates of a pair, so that such a character takes two characters of an
+// NVARCHAR column, and byte length columns like VARCHAR hold the CESU-8 bytes.
+// Strings are checked against their column length before create and update, as
+// the driver reports a generic error only. The lengths are counted with the
+// cesu8 package of go-hdb, so they match the encoding of the driver.
+
+var (
+	// ErrStringTooLong is returned if a string exceeds the length of its column
+	ErrStringTooLong = errors.New("string exceeds column length")
+	// ErrInvalidString is returned for strings which are no valid UTF-8
+	ErrInvalidString = errors.New("string is no valid UTF-8")
+)
+
+// StringLengthError is returned if a string exceeds the length of its column
+type StringLengthError struct {
+	Table  string
+	Column string
+	// Length is the length of the string as counted by HANA
+	Length int
+	// MaxLength is the length of the column
+	MaxLength int
+}
+
+func (e *StringLengthError) Error() string {
+	return fmt.Sprintf("hdb: %s of %q.%q: length %d, maximum %d", ErrStringTooLong, e.Table, e.Column, e.Length, e.MaxLength)
+}
+
+// Is reports whether target is ErrStringTooLong
+func (e *StringLengthError) Is(target error) bool {
+	return target == ErrStringTooLong
+}
+
+// StringLength returns the length of s as counted by HANA for unicode columns,
+// supplementary characters count as two
+func StringLength(s string) int {
+	length := 0
+	for _, r := range s {
+		if cesu8.RuneLen(r) == cesu8SurrogatePairLen {
+			length += 2
+		} else {
+			length++
+		}
+	}
+	return length
+}
+
+// cesu8SurrogatePairLen is the CESU-8 length of supplementary characters
+const cesu8SurrogatePairLen = 6
+
+// stringColumn is a string field with a limited column length
+type stringColumn struct {
+	field     *schema.Field
+	maxLength int
+	// bytes is set for columns with a length in bytes, like VARCHAR
+	bytes bool
+}
+
+func (c stringColumn) length(s string) int {
+	if c.bytes {
+		return cesu8.StringSize(s)
+	}
+	return StringLength(s)
+}
+
+var (
+	// stringColumns caches the string columns of a schema
+	stringColumns sync.Map
+	// regLengthDataType matches the data type name and length, e.g. nvarchar(255)
+	regLengthDataType = regexp.MustCompile(`^\s*(\w+)\s*\(\s*(\d+)\s*\)`)
+)
+
+var hanaByteLengthTypes = []string{
+	"varchar",
+	"char",
+}
+
+func stringColumnsOf(db *gorm.DB, s *schema.Schema) []stringColumn {
+	if columns, ok := stringColumns.Load(s); ok {
+		return columns.([]stringColumn)
+	}
+
+	var columns []stringColumn
+	for _, field := range s.Fields {
+		if field.DBName == "" || field.IndirectFieldType.Kind() != reflect.String {
+			continue
+		}
+
+		matches := regLengthDataType.FindStringSubmatch(db.Migrator().FullDataTypeOf(field).SQL)
+		if matches == nil || !hasLength(matches[1]) {
+			continue
+		}
+		maxLength, err := strconv.Atoi(matches[2])
+		if err != nil {
+			continue
+		}
+		columns = append(columns, stringColumn{
+			field:     field,
+			maxLength: maxLength,
+			bytes:     isDataTypeOf(matches[1], hanaByteLengthTypes),
+		})
+	}
+
+	stringColumns.Store(s, columns)
+	return columns
+}
+
+// hanaStringCallback validates, and normalizes if configured, the strings of created or
+// updated records, so that invalid strings fail with a typed error before they are sent
+func hanaStringCallback(db *gorm.DB) {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return
+	}
+
+	replaceInvalid := false
+	switch dialector := db.Dialector.(type) {
+	case *Dialector:
+		replaceInvalid = dialector.ReplaceInvalidUTF8
+	case Dialector:
+		replaceInvalid = dialector.ReplaceInvalidUTF8
+	}
+
+	columns := stringColumnsOf(db, db.Statement.Schema)
+	check := func(column stringColumn, s string) (string, error) {
+		if !utf8.ValidString(s) {
+			if !replaceInvalid {
+				return s, fmt.Errorf("hdb: %w: %q.%q", ErrInvalidString, db.Statement.Table, column.field.DBName)
+			}
+			s = strings.ToValidUTF8(s, string(utf8.RuneError))
+		}
+		if length := column.length(s); length > column.maxLength {
+			return s, &StringLengthError{Table: db.Statement.Table, Column: column.field.DBName, Length: length, MaxLength: column.maxLength}
+		}
+		return s, nil
+	}
+
+	checkStruct := func(rv reflect.Value) {
+		for _, column := range columns {
+			fieldValue := reflect.Indirect(column.field.ReflectValueOf(db.Statement.Context, rv))
+			if !fieldValue.IsValid() {
+				continue
+			}
+			s, err := check(column, fieldValue.String())
+			if err != nil {
+				db.AddError(err)
+				return
+			}
+			if s != fieldValue.String() && fieldValue.CanSet() {
+				fieldValue.SetString(s)
+			}
+		}
+	}
+
+	if dest, ok := db.Statement.Dest.(map[string]interface{}); ok {
+		for _, column := range columns {
+			for _, key := range []string{column.field.Name, column.field.DBName} {
+				if value, ok := dest[key].(string); ok {
+					s, err := check(column, value)
+					if err != nil {
+						db.AddError(err)
+						return
+					}
+					dest[key] = s
+				}
+			}
+		}
+		return
+	}
+
+	rv := reflect.Indirect(reflect.ValueOf(db.Statement.Dest))
+	if !rv.IsValid() || (rv.Kind() == reflect.Struct && rv.Type() != db.Statement.Schema.ModelType) {
+		rv = db.Statement.ReflectValue
+	}
+
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len() && db.Error == nil; i++ {
+			if elem := reflect.Indirect(rv.Index(i)); elem.Kind() == reflect.Struct {
+				checkStruct(elem)
+			}
+		}
+	case reflect.Struct:
+		if rv.Type() == db.Statement.Schema.ModelType {
+			checkStruct(rv)
+		}
+	}
+}
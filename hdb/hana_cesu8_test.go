@@ -0,0 +1,65 @@
+package hdb
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestStringLength(t *testing.T) {
+	assert.Equal(t, 0, StringLength(""))
+	assert.Equal(t, 5, StringLength("héllo"))
+	assert.Equal(t, 2, StringLength("😀"))
+	assert.Equal(t, 4, StringLength("a😀b"))
+}
+
+func TestStringValidation(t *testing.T) {
+	type Message struct {
+		ID     uint
+		Title  string   `gorm:"size:5"`
+		Code   string   `gorm:"stringtype:varchar;size:3"`
+		Note   *string  `gorm:"size:2"`
+		Body   string   `gorm:"size:10000"`
+		Number Alphanum `gorm:"stringtype:alphanum;size:4"`
+	}
+
+	db := newDryRunDB(t)
+
+	assert.Nil(t, db.Create(&Message{Title: "abc😀", Code: "abc", Body: strings.Repeat("x", 10000)}).Error)
+
+	err := db.Create(&Message{Title: "abcd😀"}).Error
+	assert.True(t, errors.Is(err, ErrStringTooLong))
+	var lengthErr *StringLengthError
+	assert.True(t, errors.As(err, &lengthErr))
+	assert.Equal(t, &StringLengthError{Table: "messages", Column: "title", Length: 6, MaxLength: 5}, lengthErr)
+
+	// varchar columns are limited in bytes
+	assert.ErrorIs(t, db.Create(&Message{Code: "äbc"}).Error, ErrStringTooLong)
+	assert.ErrorIs(t, db.Create(&[]Message{{}, {Number: "12345"}}).Error, ErrStringTooLong)
+	note := "abc"
+	assert.ErrorIs(t, db.Create(&Message{Note: &note}).Error, ErrStringTooLong)
+
+	assert.ErrorIs(t, db.Model(&Message{ID: 1}).Update("title", "toolong").Error, ErrStringTooLong)
+	assert.ErrorIs(t, db.Model(&Message{ID: 1}).Updates(map[string]interface{}{"Code": "abcd"}).Error, ErrStringTooLong)
+	assert.ErrorIs(t, db.Model(&Message{ID: 1}).Updates(Message{Title: "toolong"}).Error, ErrStringTooLong)
+	assert.Nil(t, db.Model(&Message{ID: 1}).Updates(Message{Title: "ok"}).Error)
+
+	assert.ErrorIs(t, db.Create(&Message{Title: "a\xffb"}).Error, ErrInvalidString)
+
+	db, err = gorm.Open(New(Config{Conn: dryRunConnPool{}, ReplaceInvalidUTF8: true}), &gorm.Config{
+		DryRun: true,
+		Logger: logger.Discard,
+	})
+	assert.Nil(t, err)
+	message := Message{Title: "a\xffb"}
+	assert.Nil(t, db.Create(&message).Error)
+	assert.Equal(t, "a�b", message.Title)
+
+	values := map[string]interface{}{"title": "\xff"}
+	assert.Nil(t, db.Model(&Message{ID: 1}).Updates(values).Error)
+	assert.Equal(t, "�", values["title"])
+}
@@ -1,9 +1,26 @@
 package hdb
 
-import "gorm.io/gorm"
+import (
+	"database/sql/driver"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
 
 type Config struct {
 	DriverName string
 	DSN        string
 	Conn       gorm.ConnPool
+	// Connector opens the connections if set, e.g. a go-hdb connector
+	// configured with a custom lob chunk size
+	Connector driver.Connector
+	// NamingStrategy overrides the naming strategy of gorm,
+	// use NamingStrategy{} for HANA style upper case identifiers
+	NamingStrategy schema.Namer
+	// ReplaceInvalidUTF8 replaces invalid UTF-8 sequences of created or updated
+	// strings with U+FFFD instead of failing with ErrInvalidString
+	ReplaceInvalidUTF8 bool
+	// CatalogSnapshot answers the catalog lookups of the Migrator instead of
+	// the database, without DSN, Conn and Connector no connection is opened
+	CatalogSnapshot *Snapshot
 }
@@ -0,0 +1,198 @@
+package hdb
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// DataTypeDecimal exact decimal number
+const DataTypeDecimal schema.DataType = "decimal"
+
+// Decimal is an exact decimal model field. Fields with a precision tag are
+// created as DECIMAL(p,s), others as floating point DECIMAL with 34 digits.
+// Use the `type:smalldecimal` tag for SMALLDECIMAL columns.
+//
+//	type Invoice struct {
+//		Amount hdb.Decimal `gorm:"precision:15;scale:2"`
+//	}
+type Decimal struct {
+	rat *big.Rat
+}
+
+// NewDecimal returns a Decimal of r
+func NewDecimal(r *big.Rat) Decimal {
+	return Decimal{rat: new(big.Rat).Set(r)}
+}
+
+// ParseDecimal parses a decimal like "1234.56" or a fraction like "1/3"
+func ParseDecimal(s string) (Decimal, error) {
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return Decimal{}, fmt.Errorf("decimal: invalid value %q", s)
+	}
+	return Decimal{rat: r}, nil
+}
+
+// Rat returns a copy of the decimal value
+func (d Decimal) Rat() *big.Rat {
+	if d.rat == nil {
+		return new(big.Rat)
+	}
+	return new(big.Rat).Set(d.rat)
+}
+
+// String returns the exact decimal representation
+func (d Decimal) String() string {
+	return formatDecimal(d.Rat())
+}
+
+// Cmp compares d and other
+func (d Decimal) Cmp(other Decimal) int {
+	return d.Rat().Cmp(other.Rat())
+}
+
+// GormDataType gorm common data type
+func (Decimal) GormDataType() string {
+	return string(DataTypeDecimal)
+}
+
+// GormDBDataType gorm db data type
+func (Decimal) GormDBDataType(db *gorm.DB, field *schema.Field) string {
+	return decimalDBDataType(field)
+}
+
+// Scan implements the database/sql/Scanner interface
+func (d *Decimal) Scan(src interface{}) error {
+	r, err := scanDecimal(src)
+	if err != nil {
+		return err
+	}
+	if r == nil {
+		return fmt.Errorf("decimal: can not scan NULL, use NullDecimal")
+	}
+	d.rat = r
+	return nil
+}
+
+// Value implements the database/sql/Valuer interface
+func (d Decimal) Value() (driver.Value, error) {
+	return d.Rat(), nil
+}
+
+// MarshalJSON encodes the decimal as JSON number without loss of precision
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalJSON decodes the decimal from a JSON number or string
+func (d *Decimal) UnmarshalJSON(b []byte) error {
+	if bytes.Equal(b, []byte("null")) {
+		return nil
+	}
+	if s, err := strconv.Unquote(string(b)); err == nil {
+		b = []byte(s)
+	}
+	parsed, err := ParseDecimal(string(b))
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// NullDecimal is a Decimal that may be NULL
+type NullDecimal struct {
+	Decimal Decimal
+	Valid   bool
+}
+
+// GormDataType gorm common data type
+func (NullDecimal) GormDataType() string {
+	return string(DataTypeDecimal)
+}
+
+// GormDBDataType gorm db data type
+func (NullDecimal) GormDBDataType(db *gorm.DB, field *schema.Field) string {
+	return decimalDBDataType(field)
+}
+
+// Scan implements the database/sql/Scanner interface
+func (n *NullDecimal) Scan(src interface{}) error {
+	r, err := scanDecimal(src)
+	if err != nil {
+		return err
+	}
+	n.Decimal, n.Valid = Decimal{rat: r}, r != nil
+	return nil
+}
+
+// Value implements the database/sql/Valuer interface
+func (n NullDecimal) Value() (driver.Value, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+	return n.Decimal.Value()
+}
+
+// MarshalJSON encodes the decimal as JSON number or null
+func (n NullDecimal) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return []byte("null"), nil
+	}
+	return n.Decimal.MarshalJSON()
+}
+
+// UnmarshalJSON decodes the decimal from a JSON number, string or null
+func (n *NullDecimal) UnmarshalJSON(b []byte) error {
+	if bytes.Equal(b, []byte("null")) {
+		*n = NullDecimal{}
+		return nil
+	}
+	if err := n.Decimal.UnmarshalJSON(b); err != nil {
+		return err
+	}
+	n.Valid = true
+	return nil
+}
+
+func decimalDBDataType(field *schema.Field) string {
+	if dataType, ok := field.TagSettings["TYPE"]; ok {
+		return dataType
+	}
+	if field.Precision > 0 {
+		return fmt.Sprintf("decimal(%d, %d)", field.Precision, field.Scale)
+	}
+	return string(DataTypeDecimal)
+}
+
+// scanDecimal converts the scanned value, go-hdb scans decimals as *big.Rat
+func scanDecimal(src interface{}) (*big.Rat, error) {
+	switch src := src.(type) {
+	case nil:
+		return nil, nil
+	case *big.Rat:
+		return new(big.Rat).Set(src), nil
+	case int64:
+		return new(big.Rat).SetInt64(src), nil
+	case float64:
+		if r := new(big.Rat).SetFloat64(src); r != nil {
+			return r, nil
+		}
+		return nil, fmt.Errorf("decimal: invalid value %v", src)
+	case []byte:
+		return scanDecimal(string(src))
+	case string:
+		r, ok := new(big.Rat).SetString(src)
+		if !ok {
+			return nil, fmt.Errorf("decimal: invalid value %q", src)
+		}
+		return r, nil
+	}
+	return nil, fmt.Errorf("decimal: invalid scan type %T", src)
+}
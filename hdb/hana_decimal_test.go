@@ -0,0 +1,53 @@
+package hdb
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecimal(t *testing.T) {
+	type Invoice struct {
+		ID       uint
+		Amount   Decimal `gorm:"precision:15;scale:2"`
+		Rate     Decimal
+		Discount NullDecimal `gorm:"type:smalldecimal"`
+	}
+
+	db := newDryRunDB(t)
+	stmt := db.Model(&Invoice{}).Statement
+	assert.Nil(t, stmt.Parse(&Invoice{}))
+
+	m := db.Migrator().(Migrator)
+	assert.Equal(t, "decimal(15, 2)", m.FullDataTypeOf(stmt.Schema.LookUpField("Amount")).SQL)
+	assert.Equal(t, "decimal", m.FullDataTypeOf(stmt.Schema.LookUpField("Rate")).SQL)
+	assert.Equal(t, "smalldecimal", m.FullDataTypeOf(stmt.Schema.LookUpField("Discount")).SQL)
+
+	amount, err := ParseDecimal("12345678901234567890.12")
+	assert.Nil(t, err)
+	assert.Equal(t, "12345678901234567890.12", amount.String())
+
+	value, err := amount.Value()
+	assert.Nil(t, err)
+	var scanned Decimal
+	assert.Nil(t, scanned.Scan(value))
+	assert.Zero(t, amount.Cmp(scanned))
+
+	var null NullDecimal
+	assert.Nil(t, null.Scan(nil))
+	assert.False(t, null.Valid)
+	assert.Nil(t, null.Scan(big.NewRat(1, 4)))
+	assert.Equal(t, "0.25", null.Decimal.String())
+
+	b, err := json.Marshal(Invoice{Amount: amount})
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"ID":0,"Amount":12345678901234567890.12,"Rate":0,"Discount":null}`, string(b))
+
+	var invoice Invoice
+	assert.Nil(t, json.Unmarshal([]byte(`{"Amount":"0.10","Discount":1.5}`), &invoice))
+	assert.Equal(t, "0.1", invoice.Amount.String())
+	assert.True(t, invoice.Discount.Valid)
+	assert.Equal(t, "1.5", invoice.Discount.Decimal.String())
+}
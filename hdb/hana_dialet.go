@@ -3,13 +3,12 @@ package hdb
 import (
 	"database/sql"
 	"fmt"
-	"math"
+	"strconv"
 
 	_ "github.com/SAP/go-hdb/driver"
 	"gorm.io/gorm"
 	"gorm.io/gorm/callbacks"
 	"gorm.io/gorm/clause"
-	"gorm.io/gorm/logger"
 	"gorm.io/gorm/migrator"
 	"gorm.io/gorm/schema"
 )
@@ -38,6 +37,9 @@ func (dialector Dialector) Name() string {
 }
 
 func (dialector Dialector) Apply(config *gorm.Config) error {
+	if dialector.NamingStrategy != nil {
+		config.NamingStrategy = dialector.NamingStrategy
+	}
 	return nil
 }
 
@@ -48,6 +50,8 @@ func (dialector Dialector) Initialize(db *gorm.DB) (err error) {
 		UpdateClauses: UpdateClauses,
 		DeleteClauses: DeleteClauses,
 	})
+	db.Callback().Create().Before("gorm:create").Register("hdb:strings", hanaStringCallback)
+	db.Callback().Update().Before("gorm:update").Register("hdb:strings", hanaStringCallback)
 
 	if dialector.DriverName == "" {
 		dialector.DriverName = "hdb"
@@ -55,6 +59,10 @@ func (dialector Dialector) Initialize(db *gorm.DB) (err error) {
 
 	if dialector.Conn != nil {
 		db.ConnPool = dialector.Conn
+	} else if dialector.Connector != nil {
+		db.ConnPool = sql.OpenDB(dialector.Connector)
+	} else if dialector.CatalogSnapshot != nil && dialector.DSN == "" {
+		db.ConnPool = sql.OpenDB(noConnector{})
 	} else {
 		db.ConnPool, err = sql.Open(dialector.DriverName, dialector.DSN)
 		if err != nil {
@@ -75,6 +83,10 @@ const (
 	ClauseValues = "VALUES"
 	// ClauseValues for clause.ClauseBuilder FOR key
 	ClauseFor = "FOR"
+	// ClauseSelect for clause.ClauseBuilder SELECT key
+	ClauseSelect = "SELECT"
+	// ClauseLimit for clause.ClauseBuilder LIMIT key
+	ClauseLimit = "LIMIT"
 )
 
 func (dialector Dialector) ClauseBuilders() map[string]clause.ClauseBuilder {
@@ -128,6 +140,26 @@ func (dialector Dialector) ClauseBuilders() map[string]clause.ClauseBuilder {
 			}
 			c.Build(builder)
 		},
+		ClauseSelect: func(c clause.Clause, builder clause.Builder) {
+			if stmt, ok := builder.(*gorm.Statement); ok {
+				if limit, ok := stmt.Clauses[ClauseLimit]; ok {
+					if top, ok := limit.Expression.(Top); ok && top.Limit > 0 {
+						builder.WriteString("SELECT TOP ")
+						builder.WriteString(strconv.Itoa(top.Limit))
+						builder.WriteByte(' ')
+						c.Name = ""
+					}
+				}
+			}
+			c.Build(builder)
+		},
+		ClauseLimit: func(c clause.Clause, builder clause.Builder) {
+			if limit, ok := c.Expression.(clause.Limit); ok {
+				buildLimit(limit, builder)
+				return
+			}
+			c.Build(builder)
+		},
 	}
 
 	return clauseBuilders
@@ -141,8 +173,10 @@ func (dialector Dialector) Migrator(db *gorm.DB) gorm.Migrator {
 	return Migrator{
 		Migrator: migrator.Migrator{
 			Config: migrator.Config{
-				DB:        db,
-				Dialector: dialector,
+				// HANA creates indexes with CREATE INDEX only
+				CreateIndexAfterCreateTable: true,
+				DB:                          db,
+				Dialector:                   dialector,
 			},
 		},
 		Dialector: dialector,
@@ -154,57 +188,17 @@ func (dialector Dialector) BindVarTo(writer clause.Writer, stmt *gorm.Statement,
 }
 
 func (dialector Dialector) QuoteTo(writer clause.Writer, str string) {
-	var (
-		underQuoted, selfQuoted bool
-		continuousBacktick      int8
-		shiftDelimiter          int8
-	)
-
-	for _, v := range []byte(str) {
-		switch v {
-		case '"':
-			continuousBacktick++
-			if continuousBacktick == 2 {
-				writer.WriteString(`""`)
-				continuousBacktick = 0
-			}
-		case '.':
-			if continuousBacktick > 0 || !selfQuoted {
-				shiftDelimiter = 0
-				underQuoted = false
-				continuousBacktick = 0
-				writer.WriteByte('"')
-			}
-			writer.WriteByte(v)
-			continue
-		default:
-			if shiftDelimiter-continuousBacktick <= 0 && !underQuoted {
-				writer.WriteByte('"')
-				underQuoted = true
-				if selfQuoted = continuousBacktick > 0; selfQuoted {
-					continuousBacktick -= 1
-				}
-			}
-
-			for ; continuousBacktick > 0; continuousBacktick -= 1 {
-				writer.WriteString(`""`)
-			}
-
-			writer.WriteByte(v)
-		}
-		shiftDelimiter++
-	}
-
-	if continuousBacktick > 0 && !selfQuoted {
-		writer.WriteString("``")
-	}
-	writer.WriteByte('"')
+	writeQuotedIdentifier(writer, splitIdentifier(str))
 }
 
 func (dialector Dialector) Explain(sql string, vars ...interface{}) string {
-	return logger.ExplainSQL(sql, nil, `'`, vars...)
+	return explainSQL(sql, vars...)
 }
 
+// maxVarLength is the maximum length of NVARCHAR and VARBINARY columns,
+// larger values are stored as lob
+const maxVarLength = 5000
+
 func (dialector Dialector) DataTypeOf(field *schema.Field) string {
 	switch field.DataType {
 	case schema.Bool:
@@ -237,36 +231,31 @@ func (dialector Dialector) getSchemaFloatType(field *schema.Field) string {
 }
 
 func (dialector Dialector) getSchemaStringType(field *schema.Field) string {
+	if dataType, ok := stringDataType(field); ok {
+		return dataType
+	}
+
 	size := field.Size
 	if size == 0 {
 		size = 255
 	}
+	if size > maxVarLength {
+		return string(DataTypeNClob)
+	}
 	return fmt.Sprintf("nvarchar(%d)", size)
 }
 
 func (dialector Dialector) getSchemaTimeType(field *schema.Field) string {
-	precision := ""
-
-	if field.Precision > 0 {
-		precision = fmt.Sprintf("(%d)", field.Precision)
-	}
-
-	if field.NotNull || field.PrimaryKey {
-		return "datetime" + precision
-	}
-	return "datetime" + precision + " NULL"
+	// HANA has no TIMESTAMP precision, values are stored with 100ns precision
+	return string(DataTypeTimestamp)
 }
 
 func (dialector Dialector) getSchemaBytesType(field *schema.Field) string {
-	if field.Size > 0 && field.Size < 65536 {
+	if field.Size > 0 && field.Size <= maxVarLength {
 		return fmt.Sprintf("varbinary(%d)", field.Size)
 	}
 
-	if field.Size >= 65536 && field.Size <= int(math.Pow(2, 24)) {
-		return "mediumblob"
-	}
-
-	return "longblob"
+	return string(DataTypeBlob)
 }
 
 func intFieldToType(field *schema.Field) (colType string) {
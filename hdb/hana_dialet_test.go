@@ -0,0 +1,42 @@
+package hdb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var errDryRun = errors.New("dry run connection pool can not execute statements")
+
+// dryRunConnPool is a connection pool for DryRun sessions, which never reach the database
+type dryRunConnPool struct{}
+
+func (dryRunConnPool) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return nil, errDryRun
+}
+
+func (dryRunConnPool) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return nil, errDryRun
+}
+
+func (dryRunConnPool) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return nil, errDryRun
+}
+
+func (dryRunConnPool) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return nil
+}
+
+func newDryRunDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(New(Config{Conn: dryRunConnPool{}}), &gorm.Config{
+		DryRun: true,
+		Logger: logger.Discard,
+	})
+	assert.Nil(t, err)
+	return db
+}
@@ -0,0 +1,214 @@
+package hdb
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	hdbDriver "github.com/SAP/go-hdb/driver"
+)
+
+const (
+	explainNull = "NULL"
+	// HANA keeps 7 fractional digits for TIMESTAMP values
+	explainTimestampLayout = "2006-01-02 15:04:05.0000000"
+	// maximum number of fractional digits tried when rendering a decimal exactly
+	maxDecimalScale = 38
+	// larger in-memory lob sources are not inlined into the explained statement
+	explainMaxLobSize = 1 << 16
+)
+
+// explainSQL renders sql with vars inlined as HANA literals,
+// so that the result can be executed as-is in the HANA SQL console
+func explainSQL(sql string, vars ...interface{}) string {
+	var (
+		builder strings.Builder
+		idx     int
+		quote   byte
+	)
+
+	builder.Grow(len(sql) + 16*len(vars))
+
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '?' && idx < len(vars):
+			builder.WriteString(explainVar(vars[idx]))
+			idx++
+			continue
+		}
+		builder.WriteByte(c)
+	}
+
+	return builder.String()
+}
+
+func explainVar(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return explainNull
+	case bool:
+		if v {
+			return "TRUE"
+		}
+		return "FALSE"
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprintf("%d", v)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'g', -1, 32)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case string:
+		return explainString(v)
+	case []byte:
+		if v == nil {
+			return explainNull
+		}
+		return explainBytes(v)
+	case time.Time:
+		return explainTime(v)
+	case *big.Rat:
+		if v == nil {
+			return explainNull
+		}
+		return formatDecimal(v)
+	case hdbDriver.Decimal:
+		return formatDecimal((*big.Rat)(&v))
+	case *hdbDriver.Decimal:
+		if v == nil {
+			return explainNull
+		}
+		return formatDecimal((*big.Rat)(v))
+	case hdbDriver.Lob:
+		return explainLob(v.Reader())
+	case *hdbDriver.Lob:
+		if v == nil {
+			return explainNull
+		}
+		return explainLob(v.Reader())
+	case hdbDriver.NullLob:
+		if !v.Valid || v.Lob == nil {
+			return explainNull
+		}
+		return explainLob(v.Lob.Reader())
+	case driver.Valuer:
+		rv := reflect.ValueOf(v)
+		if rv.Kind() == reflect.Ptr && rv.IsNil() {
+			return explainNull
+		}
+		value, err := v.Value()
+		if err != nil {
+			return explainNull
+		}
+		return explainVar(value)
+	case io.Reader:
+		return explainLob(v)
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Ptr:
+		if rv.IsNil() {
+			return explainNull
+		}
+		return explainVar(rv.Elem().Interface())
+	case reflect.Bool:
+		return explainVar(rv.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return explainVar(rv.Float())
+	case reflect.String:
+		return explainString(rv.String())
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return explainVar(rv.Bytes())
+		}
+	}
+
+	if t, ok := v.(fmt.Stringer); ok {
+		return explainString(t.String())
+	}
+
+	return explainString(fmt.Sprint(v))
+}
+
+// explainString quotes s as HANA string literal, strings outside of
+// the ASCII range are written as NCHAR literal
+func explainString(s string) string {
+	literal := "'" + strings.ReplaceAll(s, "'", "''") + "'"
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return "N" + literal
+		}
+	}
+	return literal
+}
+
+func explainBytes(b []byte) string {
+	return "X'" + strings.ToUpper(hex.EncodeToString(b)) + "'"
+}
+
+func explainTime(t time.Time) string {
+	// the driver always transfers time values as UTC
+	return "TO_TIMESTAMP('" + t.UTC().Format(explainTimestampLayout) + "')"
+}
+
+// formatDecimal renders r as exact decimal if possible
+func formatDecimal(r *big.Rat) string {
+	if r.IsInt() {
+		return r.Num().String()
+	}
+	for scale := 1; scale <= maxDecimalScale; scale++ {
+		s := r.FloatString(scale)
+		if parsed, ok := new(big.Rat).SetString(s); ok && parsed.Cmp(r) == 0 {
+			return s
+		}
+	}
+	return r.FloatString(maxDecimalScale)
+}
+
+// explainLob inlines in-memory lob sources without consuming them,
+// streamed sources are rendered as NULL
+func explainLob(rd io.Reader) string {
+	switch rd := rd.(type) {
+	case nil:
+		return explainNull
+	case *bytes.Reader:
+		if b, ok := peekReaderAt(rd, rd.Size(), rd.Len()); ok {
+			return explainBytes(b)
+		}
+	case *strings.Reader:
+		if b, ok := peekReaderAt(rd, rd.Size(), rd.Len()); ok {
+			return explainString(string(b))
+		}
+	}
+	return explainNull + " /* lob stream */"
+}
+
+func peekReaderAt(rd io.ReaderAt, size int64, remaining int) ([]byte, bool) {
+	if remaining > explainMaxLobSize {
+		return nil, false
+	}
+	b := make([]byte, remaining)
+	if _, err := rd.ReadAt(b, size-int64(remaining)); err != nil && err != io.EOF {
+		return nil, false
+	}
+	return b, true
+}
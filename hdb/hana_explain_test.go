@@ -0,0 +1,44 @@
+package hdb
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+	"time"
+
+	hdbDriver "github.com/SAP/go-hdb/driver"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExplain(t *testing.T) {
+	var (
+		nilString *string
+		name      = "Theo"
+		decimal   = hdbDriver.Decimal(*big.NewRat(12345, 100))
+		payload   = bytes.NewReader([]byte{0xca, 0xfe})
+	)
+
+	tests := []struct {
+		SQL    string
+		Vars   []interface{}
+		Result string
+	}{
+		{"SELECT * FROM \"users\" WHERE \"name\" = ? AND \"age\" > ?", []interface{}{"O'Neil", 18}, "SELECT * FROM \"users\" WHERE \"name\" = 'O''Neil' AND \"age\" > 18"},
+		{"SELECT ?, ?", []interface{}{"Grüße", true}, "SELECT N'Grüße', TRUE"},
+		{"SELECT ?, ?", []interface{}{nilString, &name}, "SELECT NULL, 'Theo'"},
+		{"SELECT ?", []interface{}{[]byte{0x01, 0xab}}, "SELECT X'01AB'"},
+		{"SELECT ?", []interface{}{time.Date(2022, 3, 4, 5, 6, 7, 123456700, time.UTC)}, "SELECT TO_TIMESTAMP('2022-03-04 05:06:07.1234567')"},
+		{"SELECT ?, ?", []interface{}{decimal, big.NewRat(1, 3)}, "SELECT 123.45, 0.33333333333333333333333333333333333333"},
+		{"SELECT ?, ?", []interface{}{1.5, float32(0.1)}, "SELECT 1.5, 0.1"},
+		{"SELECT ?", []interface{}{hdbDriver.NewLob(payload, nil)}, "SELECT X'CAFE'"},
+		{"SELECT '?' FROM \"a?\" WHERE x = ?", []interface{}{1}, "SELECT '?' FROM \"a?\" WHERE x = 1"},
+	}
+
+	dialector := Dialector{}
+	for _, test := range tests {
+		assert.Equal(t, test.Result, dialector.Explain(test.SQL, test.Vars...))
+	}
+
+	// explaining an in-memory lob must not consume it
+	assert.Equal(t, 2, payload.Len())
+}
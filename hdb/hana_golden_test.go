@@ -0,0 +1,268 @@
+package hdb
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+)
+
+// The golden tests run gorm operations in DryRun mode and compare the generated
+// statements with the golden files in testdata/golden, regenerate them with
+//
+//	go test ./hdb -run TestGolden -update
+var updateGolden = flag.Bool("update", false, "update golden files")
+
+type goldenCompany struct {
+	ID   uint
+	Name string `gorm:"size:100"`
+}
+
+type goldenUser struct {
+	ID        uint
+	Name      string `gorm:"size:100;index"`
+	Email     *string
+	Age       uint8
+	Balance   Decimal `gorm:"precision:15;scale:2"`
+	Birthday  Date
+	CompanyID *uint
+	Company   *goldenCompany
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt
+}
+
+var goldenTime = time.Date(2022, 3, 4, 5, 6, 7, 0, time.UTC)
+
+// goldenRecorder records the statements of all callback processors
+type goldenRecorder struct {
+	statements []string
+}
+
+func (r *goldenRecorder) record(db *gorm.DB) {
+	if db.Statement.SQL.Len() == 0 {
+		return
+	}
+
+	var builder strings.Builder
+	builder.WriteString(db.Statement.SQL.String())
+	builder.WriteString(";\n")
+	if len(db.Statement.Vars) > 0 {
+		vars := make([]string, len(db.Statement.Vars))
+		for idx, v := range db.Statement.Vars {
+			vars[idx] = explainVar(v)
+		}
+		builder.WriteString("-- vars: ")
+		builder.WriteString(strings.Join(vars, ", "))
+		builder.WriteByte('\n')
+	}
+	if db.Error != nil {
+		builder.WriteString("-- error: ")
+		builder.WriteString(db.Error.Error())
+		builder.WriteByte('\n')
+	}
+	r.statements = append(r.statements, builder.String())
+}
+
+func newGoldenDB(t *testing.T) (*gorm.DB, *goldenRecorder) {
+	db, err := gorm.Open(New(Config{Conn: dryRunConnPool{}}), &gorm.Config{
+		DryRun:  true,
+		Logger:  logger.Discard,
+		NowFunc: func() time.Time { return goldenTime },
+	})
+	assert.Nil(t, err)
+	RegisterCallbacks(db)
+
+	recorder := &goldenRecorder{}
+	callbacks := db.Callback()
+	assert.Nil(t, callbacks.Create().Register("golden:record", recorder.record))
+	assert.Nil(t, callbacks.Query().Register("golden:record", recorder.record))
+	assert.Nil(t, callbacks.Update().Register("golden:record", recorder.record))
+	assert.Nil(t, callbacks.Delete().Register("golden:record", recorder.record))
+	assert.Nil(t, callbacks.Raw().Register("golden:record", recorder.record))
+	return db, recorder
+}
+
+// assertGolden compares got with the golden file of name
+func assertGolden(t *testing.T, name, got string) {
+	t.Helper()
+
+	path := filepath.Join("testdata", "golden", name+".golden")
+	if *updateGolden {
+		assert.Nil(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.Nil(t, ioutil.WriteFile(path, []byte(got), 0644))
+		return
+	}
+
+	want, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v, run with -update to create the golden file", err)
+	}
+	assert.Equal(t, string(want), got, "golden file %s", path)
+}
+
+func TestGolden(t *testing.T) {
+	email := "jane@example.com"
+	companyID := uint(7)
+	balance, _ := ParseDecimal("1234.56")
+	birthday := Date(time.Date(1990, 5, 17, 0, 0, 0, 0, time.UTC))
+
+	cases := []struct {
+		name string
+		run  func(db *gorm.DB) error
+	}{
+		// migrations
+		{"migrate_create_table", func(db *gorm.DB) error {
+			return db.Migrator().CreateTable(&goldenCompany{}, &goldenUser{})
+		}},
+		{"migrate_drop_table", func(db *gorm.DB) error {
+			return db.Migrator().DropTable(&goldenUser{})
+		}},
+		{"migrate_add_column", func(db *gorm.DB) error {
+			return db.Migrator().AddColumn(&goldenUser{}, "Email")
+		}},
+		{"migrate_alter_column", func(db *gorm.DB) error {
+			return db.Migrator().AlterColumn(&goldenUser{}, "Name")
+		}},
+		{"migrate_rename_column", func(db *gorm.DB) error {
+			return db.Migrator().RenameColumn(&goldenUser{}, "full_name", "Name")
+		}},
+		{"migrate_drop_column", func(db *gorm.DB) error {
+			return db.Migrator().DropColumn(&goldenUser{}, "Email")
+		}},
+		{"migrate_rename_table", func(db *gorm.DB) error {
+			return db.Migrator().RenameTable("sales.users", &goldenUser{})
+		}},
+		{"migrate_create_index", func(db *gorm.DB) error {
+			return db.Migrator().CreateIndex(&goldenUser{}, "Name")
+		}},
+		{"migrate_drop_index", func(db *gorm.DB) error {
+			return db.Migrator().DropIndex(&goldenUser{}, "idx_golden_users_name")
+		}},
+		{"migrate_rename_index", func(db *gorm.DB) error {
+			return db.Table("sales.golden_users").Migrator().RenameIndex(&goldenUser{}, "idx_users_name", "idx_golden_users_name")
+		}},
+		{"migrate_create_constraint", func(db *gorm.DB) error {
+			return db.Migrator().CreateConstraint(&goldenUser{}, "Company")
+		}},
+		{"migrate_drop_constraint", func(db *gorm.DB) error {
+			return db.Migrator().DropConstraint(&goldenUser{}, "Company")
+		}},
+
+		// create
+		{"create", func(db *gorm.DB) error {
+			return db.Create(&goldenUser{Name: "jane", Email: &email, Age: 30, Balance: balance, Birthday: birthday, CompanyID: &companyID}).Error
+		}},
+		{"create_batch", func(db *gorm.DB) error {
+			return db.Create(&[]goldenUser{{Name: "a"}, {Name: "b"}}).Error
+		}},
+		{"create_select", func(db *gorm.DB) error {
+			return db.Select("Name", "Age").Create(&goldenUser{Name: "jane", Age: 30}).Error
+		}},
+		{"create_upsert", func(db *gorm.DB) error {
+			return db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&goldenCompany{ID: 1, Name: "acme"}).Error
+		}},
+		{"create_map", func(db *gorm.DB) error {
+			return db.Model(&goldenCompany{}).Create(map[string]interface{}{"Name": "acme"}).Error
+		}},
+
+		// query
+		{"query_first", func(db *gorm.DB) error {
+			return db.First(&goldenUser{}).Error
+		}},
+		{"query_first_by_id", func(db *gorm.DB) error {
+			return db.First(&goldenUser{}, 10).Error
+		}},
+		{"query_take_last", func(db *gorm.DB) error {
+			if err := db.Take(&goldenUser{}).Error; err != nil {
+				return err
+			}
+			return db.Last(&goldenUser{}).Error
+		}},
+		{"query_where", func(db *gorm.DB) error {
+			return db.Where("name = ?", "jane").Or(&goldenUser{Age: 30}).Not("age IN ?", []int{1, 2}).Find(&[]goldenUser{}).Error
+		}},
+		{"query_order_limit_offset", func(db *gorm.DB) error {
+			if err := db.Order("name").Order(clause.OrderByColumn{Column: clause.Column{Name: "age"}, Desc: true}).Limit(10).Offset(20).Find(&[]goldenUser{}).Error; err != nil {
+				return err
+			}
+			return db.Offset(5).Find(&[]goldenUser{}).Error
+		}},
+		{"query_select_group_having", func(db *gorm.DB) error {
+			var results []struct {
+				Age   uint8
+				Total int
+			}
+			return db.Model(&goldenUser{}).Select("age, COUNT(*) AS total").Group("age").Having("COUNT(*) > ?", 1).Find(&results).Error
+		}},
+		{"query_count", func(db *gorm.DB) error {
+			var count int64
+			return db.Model(&goldenUser{}).Where("age > ?", 18).Count(&count).Error
+		}},
+		{"query_pluck", func(db *gorm.DB) error {
+			var names []string
+			return db.Model(&goldenUser{}).Distinct().Pluck("name", &names).Error
+		}},
+		{"query_joins", func(db *gorm.DB) error {
+			return db.Joins("Company").Where("\"Company\".\"name\" = ?", "acme").Find(&[]goldenUser{}).Error
+		}},
+		{"query_subquery_top", func(db *gorm.DB) error {
+			return db.Where("company_id IN (?)", db.Model(&goldenCompany{}).Select("id").Clauses(Top{Limit: 3})).Find(&[]goldenUser{}).Error
+		}},
+		{"query_locking", func(db *gorm.DB) error {
+			return db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&goldenUser{}, 1).Error
+		}},
+		{"query_unscoped", func(db *gorm.DB) error {
+			return db.Unscoped().Where("deleted_at IS NOT NULL").Find(&[]goldenUser{}).Error
+		}},
+		{"query_schema_table", func(db *gorm.DB) error {
+			return db.Table("sales.orders").Where("id = ?", 1).Find(&[]map[string]interface{}{}).Error
+		}},
+
+		// update
+		{"update_column", func(db *gorm.DB) error {
+			return db.Model(&goldenUser{ID: 1}).Update("name", "john").Error
+		}},
+		{"update_struct", func(db *gorm.DB) error {
+			return db.Model(&goldenUser{ID: 1}).Updates(goldenUser{Name: "john", Age: 31}).Error
+		}},
+		{"update_map", func(db *gorm.DB) error {
+			return db.Model(&goldenUser{}).Where("age < ?", 18).Updates(map[string]interface{}{"age": gorm.Expr("age + ?", 1)}).Error
+		}},
+		{"update_update_column", func(db *gorm.DB) error {
+			return db.Model(&goldenUser{ID: 1}).UpdateColumn("age", 40).Error
+		}},
+
+		// delete
+		{"delete_soft", func(db *gorm.DB) error {
+			return db.Delete(&goldenUser{ID: 1}).Error
+		}},
+		{"delete_unscoped", func(db *gorm.DB) error {
+			return db.Unscoped().Where("age > ?", 100).Delete(&goldenUser{}).Error
+		}},
+		{"delete_by_ids", func(db *gorm.DB) error {
+			return db.Delete(&goldenCompany{}, []int{1, 2, 3}).Error
+		}},
+
+		// raw
+		{"raw_exec", func(db *gorm.DB) error {
+			return db.Exec("UPDATE ? SET ? = ? WHERE created_at < ?", clause.Table{Name: "golden_users"}, clause.Column{Name: "age"}, 0, goldenTime).Error
+		}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			db, recorder := newGoldenDB(t)
+			assert.Nil(t, c.run(db))
+			assertGolden(t, c.name, strings.Join(recorder.statements, ""))
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package hdb
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// HDIArtifact is a design-time file deploying a database object into an SAP
+// HDI container, which doesn't allow DDL statements outside of the deployment
+type HDIArtifact struct {
+	// Name is the file name, e.g. ORDERS.hdbtable
+	Name string
+	// Content is the definition of the object
+	Content string
+}
+
+// HDIArtifacts returns the .hdbtable and .hdbindex artifacts of the tables
+// AutoMigrate would create for values on an empty schema, e.g.
+//
+//	artifacts, err := db.Migrator().(hdb.Migrator).HDIArtifacts(&User{}, &Order{})
+//
+// The objects of HDI containers belong to the container's schema, so the
+// tables of values must not be schema qualified.
+func (m Migrator) HDIArtifacts(values ...interface{}) ([]HDIArtifact, error) {
+	db, err := gorm.Open(New(Config{CatalogSnapshot: &Snapshot{}}), &gorm.Config{
+		NamingStrategy:                           m.DB.NamingStrategy,
+		Logger:                                   m.DB.Logger,
+		DisableForeignKeyConstraintWhenMigrating: m.DB.DisableForeignKeyConstraintWhenMigrating,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		defer sqlDB.Close()
+	}
+
+	script, err := db.Migrator().(Migrator).AutoMigrateScript(values...)
+	if err != nil {
+		return nil, err
+	}
+
+	artifacts := make([]HDIArtifact, 0, len(script.Up))
+	for _, stmt := range script.Up {
+		artifact, err := hdiArtifact(stmt)
+		if err != nil {
+			return nil, err
+		}
+		artifacts = append(artifacts, artifact)
+	}
+	return artifacts, nil
+}
+
+// hdiArtifact returns the artifact of a CREATE TABLE or CREATE INDEX statement
+func hdiArtifact(stmt string) (HDIArtifact, error) {
+	var (
+		match  []string
+		suffix string
+	)
+	if match = createTableRegexp.FindStringSubmatch(stmt); match != nil {
+		suffix = ".hdbtable"
+	} else if match = createIndexRegexp.FindStringSubmatch(stmt); match != nil {
+		suffix = ".hdbindex"
+	} else {
+		return HDIArtifact{}, fmt.Errorf("hdb: no HDI artifact for %s", stmt)
+	}
+
+	parts := splitIdentifier(match[1])
+	if len(parts) != 1 {
+		return HDIArtifact{}, fmt.Errorf("hdb: %s is schema qualified, HDI objects belong to the container schema", match[1])
+	}
+
+	content := strings.TrimPrefix(stmt, "CREATE ")
+	if suffix == ".hdbtable" {
+		content = formatHDITable(content)
+	}
+	return HDIArtifact{Name: parts[0] + suffix, Content: content + "\n"}, nil
+}
+
+// formatHDITable returns the table definition as column table with one
+// column or constraint per line. gorm adds the foreign keys and checks in
+// map order, so the constraints are sorted by name for stable artifacts.
+func formatHDITable(definition string) string {
+	if strings.HasPrefix(definition, "TABLE ") {
+		definition = "COLUMN " + definition
+	}
+	start := strings.IndexByte(definition, '(')
+	if start < 0 {
+		return definition
+	}
+
+	var (
+		elements []string
+		depth    int
+		quote    byte
+		last     = start + 1
+		end      = -1
+	)
+	for i := start + 1; i < len(definition) && end < 0; i++ {
+		c := definition[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')' && depth > 0:
+			depth--
+		case c == ',' && depth == 0, c == ')':
+			elements = append(elements, strings.TrimSpace(definition[last:i]))
+			last = i + 1
+			if c == ')' {
+				end = i
+			}
+		}
+	}
+	if end < 0 {
+		return definition
+	}
+
+	constraints := len(elements)
+	for constraints > 0 && strings.HasPrefix(elements[constraints-1], "CONSTRAINT ") {
+		constraints--
+	}
+	sort.Strings(elements[constraints:])
+
+	return definition[:start+1] + "\n  " + strings.Join(elements, ",\n  ") + "\n" + definition[end:]
+}
+
+// HDIArtifact returns the .hdbsequence artifact of the sequence. The start and
+// minimum values are always written, as zero is a valid value for both, while
+// a zero increment defaults to 1 and a zero maximum value to NO MAXVALUE, as
+// neither is a valid value for ascending sequences.
+func (s CatalogSequence) HDIArtifact() HDIArtifact {
+	increment := s.Increment
+	if increment == 0 {
+		increment = 1
+	}
+	content := fmt.Sprintf("SEQUENCE %s START WITH %d INCREMENT BY %d MINVALUE %d",
+		quoteIdentifier(s.Name), s.StartValue, increment, s.MinValue)
+	if s.MaxValue != 0 {
+		content += fmt.Sprintf(" MAXVALUE %d", s.MaxValue)
+	} else {
+		content += " NO MAXVALUE"
+	}
+	if s.Cycle {
+		content += " CYCLE\n"
+	} else {
+		content += " NO CYCLE\n"
+	}
+	return HDIArtifact{Name: s.Name + ".hdbsequence", Content: content}
+}
+
+// HDIArtifact returns the .hdbview artifact of the view
+func (v CatalogView) HDIArtifact() HDIArtifact {
+	content := "VIEW " + quoteIdentifier(v.Name)
+	if v.Comment != "" {
+		content += " COMMENT " + explainSQL("?", v.Comment)
+	}
+	content += " AS " + strings.TrimSpace(v.Definition) + "\n"
+	return HDIArtifact{Name: v.Name + ".hdbview", Content: content}
+}
@@ -0,0 +1,110 @@
+package hdb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type hdiAuthor struct {
+	ID    uint      `gorm:"primaryKey"`
+	Name  string    `gorm:"size:100;not null;uniqueIndex"`
+	Books []hdiBook `gorm:"many2many:hdi_author_books"`
+}
+
+type hdiBook struct {
+	ID    uint    `gorm:"primaryKey;autoIncrement:false"`
+	Title string  `gorm:"size:200;index"`
+	Price float64 `gorm:"type:decimal(10,2);default:0;comment:net, in EUR"`
+}
+
+type hdiQualified struct {
+	ID uint `gorm:"primaryKey"`
+}
+
+func (hdiQualified) TableName() string {
+	return "SALES.hdi_qualified"
+}
+
+func TestHDIArtifacts(t *testing.T) {
+	// the artifacts don't depend on the catalog of the database
+	db := newScriptDB(t)
+
+	artifacts, err := db.Migrator().(Migrator).HDIArtifacts(&hdiAuthor{}, &hdiBook{})
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, []HDIArtifact{
+		{Name: "hdi_authors.hdbtable", Content: "COLUMN TABLE \"hdi_authors\" (\n" +
+			"  \"id\" bigint GENERATED BY DEFAULT AS IDENTITY,\n" +
+			"  \"name\" nvarchar(100) NOT NULL,\n" +
+			"  PRIMARY KEY (\"id\")\n)\n"},
+		{Name: "idx_hdi_authors_name.hdbindex", Content: "UNIQUE INDEX \"idx_hdi_authors_name\" ON \"hdi_authors\"(\"name\")\n"},
+		{Name: "hdi_books.hdbtable", Content: "COLUMN TABLE \"hdi_books\" (\n" +
+			"  \"id\" bigint,\n" +
+			"  \"title\" nvarchar(200),\n" +
+			"  \"price\" decimal(10,2) DEFAULT 0 COMMENT 'net, in EUR',\n" +
+			"  PRIMARY KEY (\"id\")\n)\n"},
+		{Name: "idx_hdi_books_title.hdbindex", Content: "INDEX \"idx_hdi_books_title\" ON \"hdi_books\"(\"title\")\n"},
+		{Name: "hdi_author_books.hdbtable", Content: "COLUMN TABLE \"hdi_author_books\" (\n" +
+			"  \"hdi_author_id\" bigint,\n" +
+			"  \"hdi_book_id\" bigint,\n" +
+			"  PRIMARY KEY (\"hdi_author_id\",\"hdi_book_id\"),\n" +
+			"  CONSTRAINT \"fk_hdi_author_books_hdi_author\" FOREIGN KEY (\"hdi_author_id\") REFERENCES \"hdi_authors\"(\"id\"),\n" +
+			"  CONSTRAINT \"fk_hdi_author_books_hdi_book\" FOREIGN KEY (\"hdi_book_id\") REFERENCES \"hdi_books\"(\"id\")\n)\n"},
+	}, artifacts)
+
+	_, err = db.Migrator().(Migrator).HDIArtifacts(&hdiQualified{})
+	assert.NotNil(t, err)
+}
+
+func TestHDIArtifactsNaming(t *testing.T) {
+	db, err := gorm.Open(New(Config{CatalogSnapshot: &Snapshot{}, NamingStrategy: NamingStrategy{}}), &gorm.Config{Logger: logger.Discard})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	artifacts, err := db.Migrator().(Migrator).HDIArtifacts(&hdiBook{})
+	if assert.Nil(t, err) && assert.Len(t, artifacts, 2) {
+		assert.Equal(t, "HDI_BOOKS.hdbtable", artifacts[0].Name)
+		assert.Equal(t, "IDX_HDI_BOOKS_TITLE.hdbindex", artifacts[1].Name)
+	}
+}
+
+func TestSequenceViewHDIArtifact(t *testing.T) {
+	assert.Equal(t, HDIArtifact{
+		Name:    "ORDER_NO.hdbsequence",
+		Content: "SEQUENCE \"ORDER_NO\" START WITH 1000 INCREMENT BY 1 MINVALUE 1 MAXVALUE 999999 CYCLE\n",
+	}, CatalogSequence{Name: "ORDER_NO", StartValue: 1000, Increment: 1, MinValue: 1, MaxValue: 999999, Cycle: true}.HDIArtifact())
+	// zero start and minimum values are kept, a missing increment and maximum value are defaulted
+	assert.Equal(t, HDIArtifact{
+		Name:    "S.hdbsequence",
+		Content: "SEQUENCE \"S\" START WITH 0 INCREMENT BY 1 MINVALUE 0 NO MAXVALUE NO CYCLE\n",
+	}, CatalogSequence{Name: "S"}.HDIArtifact())
+	assert.Equal(t, HDIArtifact{
+		Name:    "DOWN.hdbsequence",
+		Content: "SEQUENCE \"DOWN\" START WITH -1 INCREMENT BY -1 MINVALUE -100 MAXVALUE -1 NO CYCLE\n",
+	}, CatalogSequence{Name: "DOWN", StartValue: -1, Increment: -1, MinValue: -100, MaxValue: -1}.HDIArtifact())
+
+	assert.Equal(t, HDIArtifact{
+		Name:    "OPEN_ORDERS.hdbview",
+		Content: "VIEW \"OPEN_ORDERS\" COMMENT 'customer''s open orders' AS SELECT * FROM \"ORDERS\" WHERE \"STATE\" = 'open'\n",
+	}, CatalogView{
+		Name:       "OPEN_ORDERS",
+		Definition: "SELECT * FROM \"ORDERS\" WHERE \"STATE\" = 'open'\n",
+		Comment:    "customer's open orders",
+	}.HDIArtifact())
+}
+
+func TestFormatHDITable(t *testing.T) {
+	// constraints are sorted by name, columns keep their order
+	assert.Equal(t, "COLUMN TABLE \"t\" (\n"+
+		"  \"b\" decimal(10,2) COMMENT 'x, y',\n"+
+		"  \"a\" bigint,\n"+
+		"  CONSTRAINT \"chk_t_a\" CHECK (\"a\" > 0),\n"+
+		"  CONSTRAINT \"fk_t_b\" FOREIGN KEY (\"b\") REFERENCES \"u\"(\"id\")\n)",
+		formatHDITable(`TABLE "t" ("b" decimal(10,2) COMMENT 'x, y',"a" bigint,`+
+			`CONSTRAINT "fk_t_b" FOREIGN KEY ("b") REFERENCES "u"("id"),CONSTRAINT "chk_t_a" CHECK ("a" > 0))`))
+}
@@ -0,0 +1,234 @@
+package hdb
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// DataTypeJSON JSON document
+const DataTypeJSON schema.DataType = "json"
+
+// JSON documents are stored as NCLOB, JSON collections of the HANA document store
+// can not be used as column type. Query them with the HANA JSON functions:
+//
+//	db.Where("? = ?", hdb.JSONValue("attributes", "$.color"), "red").Find(&products)
+//	db.Table("?, ?", clause.Table{Name: "products"}, hdb.JSONTable("products.attributes", "$.tags[*]",
+//		hdb.JSONTableColumn{Name: "tag", Type: "nvarchar(100)", Path: "$"},
+//	).As("t")).Select(`"t"."tag"`).Find(&tags)
+
+// JSON is a JSON document model field, NULL and empty documents are read as nil
+type JSON json.RawMessage
+
+// NewJSON marshals v as JSON document
+func NewJSON(v interface{}) (JSON, error) {
+	b, err := json.Marshal(v)
+	return JSON(b), err
+}
+
+// Unmarshal decodes the document into v
+func (j JSON) Unmarshal(v interface{}) error {
+	return json.Unmarshal(j, v)
+}
+
+func (j JSON) String() string {
+	return string(j)
+}
+
+// GormDataType gorm common data type
+func (JSON) GormDataType() string {
+	return string(DataTypeJSON)
+}
+
+// GormDBDataType gorm db data type
+func (JSON) GormDBDataType(db *gorm.DB, field *schema.Field) string {
+	if dataType, ok := field.TagSettings["TYPE"]; ok {
+		return dataType
+	}
+	return string(DataTypeNClob)
+}
+
+// Scan implements the database/sql/Scanner interface
+func (j *JSON) Scan(src interface{}) error {
+	var buffer bytes.Buffer
+	switch src := src.(type) {
+	case nil:
+		*j = nil
+		return nil
+	case interface{ SetWriter(w io.Writer) error }:
+		if err := src.SetWriter(&buffer); err != nil {
+			return err
+		}
+	case []byte:
+		buffer.Write(src)
+	case string:
+		buffer.WriteString(src)
+	default:
+		return fmt.Errorf("json: invalid scan type %T", src)
+	}
+
+	if buffer.Len() == 0 {
+		*j = nil
+		return nil
+	}
+	if !json.Valid(buffer.Bytes()) {
+		return fmt.Errorf("json: invalid document %q", buffer.String())
+	}
+	*j = buffer.Bytes()
+	return nil
+}
+
+// Value implements the database/sql/Valuer interface, the driver accepts
+// NCLOB values as bytes only
+func (j JSON) Value() (driver.Value, error) {
+	if len(j) == 0 {
+		return nil, nil
+	}
+	if !json.Valid(j) {
+		return nil, fmt.Errorf("json: invalid document %q", string(j))
+	}
+	return []byte(j), nil
+}
+
+// MarshalJSON returns the document, nil as null
+func (j JSON) MarshalJSON() ([]byte, error) {
+	if len(j) == 0 {
+		return []byte("null"), nil
+	}
+	return j, nil
+}
+
+// UnmarshalJSON sets the document to a copy of b
+func (j *JSON) UnmarshalJSON(b []byte) error {
+	if j == nil {
+		return errors.New("json: UnmarshalJSON on nil pointer")
+	}
+	if bytes.Equal(b, []byte("null")) {
+		*j = nil
+		return nil
+	}
+	*j = append((*j)[0:0], b...)
+	return nil
+}
+
+// JSONValueExpression is a JSON_VALUE expression
+type JSONValueExpression struct {
+	column    string
+	path      string
+	returning string
+}
+
+// JSONValue returns the scalar value at path of the JSON document of column
+func JSONValue(column, path string) JSONValueExpression {
+	return JSONValueExpression{column: column, path: path}
+}
+
+// Returning sets the data type of the value, NVARCHAR(5000) by default
+func (e JSONValueExpression) Returning(dataType string) JSONValueExpression {
+	e.returning = dataType
+	return e
+}
+
+// Build implements the clause.Expression interface
+func (e JSONValueExpression) Build(builder clause.Builder) {
+	builder.WriteString("JSON_VALUE(")
+	writeJSONSource(builder, e.column, e.path)
+	if e.returning != "" {
+		builder.WriteString(" RETURNING ")
+		builder.WriteString(e.returning)
+	}
+	builder.WriteByte(')')
+}
+
+// JSONQueryExpression is a JSON_QUERY expression
+type JSONQueryExpression struct {
+	column  string
+	path    string
+	wrapper bool
+}
+
+// JSONQuery returns the object or array at path of the JSON document of column
+func JSONQuery(column, path string) JSONQueryExpression {
+	return JSONQueryExpression{column: column, path: path}
+}
+
+// WithWrapper wraps the result in an array, required if path matches scalar values
+func (e JSONQueryExpression) WithWrapper() JSONQueryExpression {
+	e.wrapper = true
+	return e
+}
+
+// Build implements the clause.Expression interface
+func (e JSONQueryExpression) Build(builder clause.Builder) {
+	builder.WriteString("JSON_QUERY(")
+	writeJSONSource(builder, e.column, e.path)
+	if e.wrapper {
+		builder.WriteString(" WITH ARRAY WRAPPER")
+	}
+	builder.WriteByte(')')
+}
+
+// JSONTableColumn is a relational column of a JSON_TABLE
+type JSONTableColumn struct {
+	Name string
+	// Type is the column data type, e.g. nvarchar(100)
+	Type string
+	// Path is the path relative to the row path
+	Path string
+}
+
+// JSONTableExpression is a JSON_TABLE expression
+type JSONTableExpression struct {
+	column  string
+	path    string
+	columns []JSONTableColumn
+	alias   string
+}
+
+// JSONTable returns a row for each match of path in the JSON document of column,
+// the column has to be qualified by its table, which precedes the JSON_TABLE in the from list
+func JSONTable(column, path string, columns ...JSONTableColumn) JSONTableExpression {
+	return JSONTableExpression{column: column, path: path, columns: columns}
+}
+
+// As sets the table alias
+func (e JSONTableExpression) As(alias string) JSONTableExpression {
+	e.alias = alias
+	return e
+}
+
+// Build implements the clause.Expression interface
+func (e JSONTableExpression) Build(builder clause.Builder) {
+	builder.WriteString("JSON_TABLE(")
+	writeJSONSource(builder, e.column, e.path)
+	builder.WriteString(" COLUMNS (")
+	for idx, column := range e.columns {
+		if idx > 0 {
+			builder.WriteByte(',')
+		}
+		builder.WriteQuoted(column.Name)
+		builder.WriteByte(' ')
+		builder.WriteString(column.Type)
+		builder.WriteString(" PATH ")
+		builder.WriteString(explainString(column.Path))
+	}
+	builder.WriteString("))")
+	if e.alias != "" {
+		builder.WriteString(" AS ")
+		builder.WriteQuoted(e.alias)
+	}
+}
+
+// writeJSONSource writes the document column and path, HANA requires the path as literal
+func writeJSONSource(builder clause.Builder, column, path string) {
+	builder.WriteQuoted(clause.Column{Name: column})
+	builder.WriteString(", ")
+	builder.WriteString(explainString(path))
+}
@@ -0,0 +1,63 @@
+package hdb
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm/clause"
+)
+
+func TestJSON(t *testing.T) {
+	type Product struct {
+		ID         uint
+		Attributes JSON
+		Raw        JSON `gorm:"type:nvarchar(5000)"`
+	}
+
+	db := newDryRunDB(t)
+	stmt := db.Model(&Product{}).Statement
+	assert.Nil(t, stmt.Parse(&Product{}))
+	m := db.Migrator().(Migrator)
+	assert.Equal(t, "nclob", m.FullDataTypeOf(stmt.Schema.LookUpField("Attributes")).SQL)
+	assert.Equal(t, "nvarchar(5000)", m.FullDataTypeOf(stmt.Schema.LookUpField("Raw")).SQL)
+
+	attributes, err := NewJSON(map[string]interface{}{"color": "red", "tags": []string{"a", "b"}})
+	assert.Nil(t, err)
+	value, err := attributes.Value()
+	assert.Nil(t, err)
+	assert.Equal(t, []byte(`{"color":"red","tags":["a","b"]}`), value)
+
+	value, err = JSON(nil).Value()
+	assert.Nil(t, err)
+	assert.Nil(t, value)
+	_, err = JSON("{").Value()
+	assert.NotNil(t, err)
+
+	var scanned JSON
+	assert.Nil(t, scanned.Scan(`{"color":"blue"}`))
+	var decoded struct{ Color string }
+	assert.Nil(t, scanned.Unmarshal(&decoded))
+	assert.Equal(t, "blue", decoded.Color)
+	assert.Nil(t, scanned.Scan(nil))
+	assert.Nil(t, scanned)
+	assert.NotNil(t, scanned.Scan([]byte("{")))
+
+	b, err := json.Marshal(struct{ A, B JSON }{A: JSON(`[1]`)})
+	assert.Nil(t, err)
+	assert.Equal(t, `{"A":[1],"B":null}`, string(b))
+
+	query := db.Where("? = ?", JSONValue("attributes", "$.color"), "red").
+		Where("? > ?", JSONValue("attributes", "$.size").Returning("integer"), 3).
+		Where("? IS NOT NULL", JSONQuery("attributes", "$.tags").WithWrapper()).
+		Find(&[]Product{}).Statement
+	assert.Equal(t, `SELECT * FROM "products" WHERE JSON_VALUE("attributes", '$.color') = ? AND JSON_VALUE("attributes", '$.size' RETURNING integer) > ? AND JSON_QUERY("attributes", '$.tags' WITH ARRAY WRAPPER) IS NOT NULL`, query.SQL.String())
+	assert.Equal(t, []interface{}{"red", 3}, query.Vars)
+
+	var tags []string
+	query = db.Table("?, ?", clause.Table{Name: "products"}, JSONTable("products.attributes", "$.tags[*]",
+		JSONTableColumn{Name: "tag", Type: "nvarchar(100)", Path: "$"},
+		JSONTableColumn{Name: "it's", Type: "integer", Path: "$.o'k"},
+	).As("t")).Select(`"t"."tag"`).Find(&tags).Statement
+	assert.Equal(t, `SELECT "t"."tag" FROM "products", JSON_TABLE("products"."attributes", '$.tags[*]' COLUMNS ("tag" nvarchar(100) PATH '$',"it's" integer PATH '$.o''k')) AS "t"`, query.SQL.String())
+}
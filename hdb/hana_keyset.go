@@ -0,0 +1,288 @@
+package hdb
+
+import (
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrInvalidCursor is returned for keyset cursor tokens which can not be decoded
+var ErrInvalidCursor = errors.New("invalid keyset cursor")
+
+// KeysetColumn is a column of the ordered key used for keyset pagination
+type KeysetColumn struct {
+	// Name is the database column name
+	Name string
+	Desc bool
+}
+
+// Keyset paginates by an ordered composite key instead of an OFFSET, so that
+// each page is read with an index seek. The key has to be unique, e.g. end
+// with the primary key.
+//
+//	keyset := hdb.Keyset{Columns: []hdb.KeysetColumn{{Name: "created_at"}, {Name: "id"}}, Limit: 100}
+//	db.Scopes(keyset.Scope).Find(&orders)
+//	keyset.Cursor, err = keyset.Next(db, &orders)
+type Keyset struct {
+	Columns []KeysetColumn
+	// Cursor is the opaque token of the last row read, empty for the first page
+	Cursor string
+	Limit  int
+}
+
+// Scope adds the seek condition, the key order and the limit to db
+func (k Keyset) Scope(db *gorm.DB) *gorm.DB {
+	orderBy := clause.OrderBy{}
+	for _, column := range k.Columns {
+		orderBy.Columns = append(orderBy.Columns, clause.OrderByColumn{
+			Column: clause.Column{Table: clause.CurrentTable, Name: column.Name},
+			Desc:   column.Desc,
+		})
+	}
+	db = db.Clauses(orderBy)
+
+	if k.Cursor != "" {
+		values, err := decodeCursor(k.Cursor)
+		if err != nil {
+			db.AddError(err)
+			return db
+		}
+		if len(values) != len(k.Columns) {
+			db.AddError(fmt.Errorf("%w: expected %d values, got %d", ErrInvalidCursor, len(k.Columns), len(values)))
+			return db
+		}
+		db = db.Where(k.seekCondition(values))
+	}
+
+	if k.Limit > 0 {
+		db = db.Limit(k.Limit)
+	}
+	return db
+}
+
+// seekCondition expands (a, b, c) > (?, ?, ?) to
+// a > ? OR (a = ? AND b > ?) OR (a = ? AND b = ? AND c > ?)
+// as HANA does not support row value comparisons
+func (k Keyset) seekCondition(values []interface{}) clause.Expression {
+	conditions := make([]clause.Expression, 0, len(k.Columns))
+
+	for idx, column := range k.Columns {
+		exprs := make([]clause.Expression, 0, idx+1)
+		for i := 0; i < idx; i++ {
+			exprs = append(exprs, clause.Eq{Column: k.column(i), Value: values[i]})
+		}
+
+		if column.Desc {
+			exprs = append(exprs, clause.Lt{Column: k.column(idx), Value: values[idx]})
+		} else {
+			exprs = append(exprs, clause.Gt{Column: k.column(idx), Value: values[idx]})
+		}
+		conditions = append(conditions, clause.And(exprs...))
+	}
+
+	return clause.Or(conditions...)
+}
+
+func (k Keyset) column(idx int) clause.Column {
+	return clause.Column{Table: clause.CurrentTable, Name: k.Columns[idx].Name}
+}
+
+// Next returns the cursor of the last row of dest, which is a pointer to
+// the slice or struct read with Scope. An empty dest returns an empty cursor.
+func (k Keyset) Next(db *gorm.DB, dest interface{}) (string, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(dest); err != nil {
+		return "", err
+	}
+
+	row := reflect.Indirect(reflect.ValueOf(dest))
+	if row.Kind() == reflect.Slice || row.Kind() == reflect.Array {
+		if row.Len() == 0 {
+			return "", nil
+		}
+		row = reflect.Indirect(row.Index(row.Len() - 1))
+	}
+
+	values := make([]interface{}, 0, len(k.Columns))
+	for _, column := range k.Columns {
+		field := stmt.Schema.LookUpField(column.Name)
+		if field == nil {
+			return "", fmt.Errorf("failed to look up field with name: %s", column.Name)
+		}
+		value, _ := field.ValueOf(db.Statement.Context, row)
+		values = append(values, value)
+	}
+
+	return encodeCursor(values)
+}
+
+// FindInBatches finds records in batches of batchSize ordered by the key,
+// it behaves like gorm's FindInBatches but seeks instead of ordering by primary key
+func (k Keyset) FindInBatches(db *gorm.DB, dest interface{}, batchSize int, fc func(tx *gorm.DB, batch int) error) *gorm.DB {
+	var (
+		tx           = db.Session(&gorm.Session{})
+		rowsAffected int64
+		batch        int
+	)
+
+	// without limit the last batch is never detected
+	if batchSize <= 0 {
+		tx.AddError(fmt.Errorf("keyset: invalid batch size %d", batchSize))
+		return tx
+	}
+
+	k.Limit = batchSize
+	for {
+		result := tx.Scopes(k.Scope).Find(dest)
+		rowsAffected += result.RowsAffected
+		batch++
+
+		if result.Error == nil && result.RowsAffected != 0 {
+			tx.AddError(fc(result, batch))
+		} else if result.Error != nil {
+			tx.AddError(result.Error)
+		}
+
+		if tx.Error != nil || int(result.RowsAffected) < batchSize {
+			break
+		}
+
+		cursor, err := k.Next(tx, dest)
+		if err != nil {
+			tx.AddError(err)
+			break
+		}
+		k.Cursor = cursor
+	}
+
+	tx.RowsAffected = rowsAffected
+	return tx
+}
+
+// cursorValue is a typed key value in the cursor token,
+// so that values are bound with their original type
+type cursorValue struct {
+	Type  string `json:"t"`
+	Value string `json:"v"`
+}
+
+const (
+	cursorInt     = "i"
+	cursorUint    = "u"
+	cursorFloat   = "f"
+	cursorBool    = "b"
+	cursorString  = "s"
+	cursorBytes   = "x"
+	cursorTime    = "t"
+	cursorDecimal = "d"
+)
+
+func encodeCursor(values []interface{}) (string, error) {
+	encoded := make([]cursorValue, 0, len(values))
+
+	for _, value := range values {
+		if valuer, ok := value.(driver.Valuer); ok {
+			v, err := valuer.Value()
+			if err != nil {
+				return "", err
+			}
+			value = v
+		}
+
+		var cv cursorValue
+		switch v := value.(type) {
+		case *big.Rat:
+			cv = cursorValue{cursorDecimal, v.RatString()}
+		case time.Time:
+			cv = cursorValue{cursorTime, v.Format(time.RFC3339Nano)}
+		case []byte:
+			cv = cursorValue{cursorBytes, base64.StdEncoding.EncodeToString(v)}
+		case string:
+			cv = cursorValue{cursorString, v}
+		case bool:
+			cv = cursorValue{cursorBool, strconv.FormatBool(v)}
+		default:
+			rv := reflect.ValueOf(value)
+			switch rv.Kind() {
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+				cv = cursorValue{cursorInt, strconv.FormatInt(rv.Int(), 10)}
+			case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+				cv = cursorValue{cursorUint, strconv.FormatUint(rv.Uint(), 10)}
+			case reflect.Float32, reflect.Float64:
+				cv = cursorValue{cursorFloat, strconv.FormatFloat(rv.Float(), 'g', -1, 64)}
+			case reflect.String:
+				cv = cursorValue{cursorString, rv.String()}
+			default:
+				return "", fmt.Errorf("unsupported keyset value %T", value)
+			}
+		}
+		encoded = append(encoded, cv)
+	}
+
+	b, err := json.Marshal(encoded)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func decodeCursor(cursor string) ([]interface{}, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+
+	var encoded []cursorValue
+	if err := json.Unmarshal(b, &encoded); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+
+	values := make([]interface{}, 0, len(encoded))
+	for _, cv := range encoded {
+		var (
+			value interface{}
+			err   error
+		)
+
+		switch cv.Type {
+		case cursorInt:
+			value, err = strconv.ParseInt(cv.Value, 10, 64)
+		case cursorUint:
+			value, err = strconv.ParseUint(cv.Value, 10, 64)
+		case cursorFloat:
+			value, err = strconv.ParseFloat(cv.Value, 64)
+		case cursorBool:
+			value, err = strconv.ParseBool(cv.Value)
+		case cursorString:
+			value = cv.Value
+		case cursorBytes:
+			value, err = base64.StdEncoding.DecodeString(cv.Value)
+		case cursorTime:
+			value, err = time.Parse(time.RFC3339Nano, cv.Value)
+		case cursorDecimal:
+			r, ok := new(big.Rat).SetString(cv.Value)
+			if !ok {
+				err = fmt.Errorf("invalid decimal %q", cv.Value)
+			}
+			value = r
+		default:
+			err = fmt.Errorf("unknown value type %q", cv.Type)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+		}
+		values = append(values, value)
+	}
+
+	return values, nil
+}
@@ -0,0 +1,51 @@
+package hdb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestKeyset(t *testing.T) {
+	type Order struct {
+		ID        uint
+		CreatedAt time.Time
+	}
+
+	db := newDryRunDB(t)
+	keyset := Keyset{Columns: []KeysetColumn{{Name: "created_at", Desc: true}, {Name: "id"}}, Limit: 2}
+
+	stmt := db.Scopes(keyset.Scope).Find(&[]Order{}).Statement
+	assert.Equal(t, `SELECT * FROM "orders" ORDER BY "orders"."created_at" DESC,"orders"."id" LIMIT 2`, stmt.SQL.String())
+
+	createdAt := time.Date(2022, 3, 4, 5, 6, 7, 8, time.UTC)
+	orders := []Order{{ID: 41, CreatedAt: createdAt.Add(time.Hour)}, {ID: 42, CreatedAt: createdAt}}
+	cursor, err := keyset.Next(db, &orders)
+	assert.Nil(t, err)
+	assert.NotEmpty(t, cursor)
+
+	keyset.Cursor = cursor
+	stmt = db.Scopes(keyset.Scope).Find(&[]Order{}).Statement
+	assert.Equal(t, `SELECT * FROM "orders" WHERE ("orders"."created_at" < ? OR ("orders"."created_at" = ? AND "orders"."id" > ?)) ORDER BY "orders"."created_at" DESC,"orders"."id" LIMIT 2`, stmt.SQL.String())
+	assert.Equal(t, []interface{}{createdAt, createdAt, uint64(42)}, stmt.Vars)
+
+	empty, err := keyset.Next(db, &[]Order{})
+	assert.Nil(t, err)
+	assert.Empty(t, empty)
+
+	keyset.Cursor = "not a cursor"
+	assert.ErrorIs(t, db.Scopes(keyset.Scope).Find(&[]Order{}).Error, ErrInvalidCursor)
+
+	keyset.Cursor = ""
+	batches := 0
+	countBatches := func(tx *gorm.DB, batch int) error {
+		batches++
+		return nil
+	}
+	assert.Nil(t, keyset.FindInBatches(db, &[]Order{}, 2, countBatches).Error)
+	assert.EqualError(t, keyset.FindInBatches(db, &[]Order{}, 0, countBatches).Error, "keyset: invalid batch size 0")
+	assert.NotNil(t, keyset.FindInBatches(db, &[]Order{}, -1, countBatches).Error)
+	assert.Equal(t, 0, batches)
+}
@@ -0,0 +1,55 @@
+package hdb
+
+import (
+	"math"
+	"strconv"
+
+	"gorm.io/gorm/clause"
+)
+
+// maxLimit is used as row count when only an offset is given,
+// HANA does not accept an OFFSET without a LIMIT
+const maxLimit = math.MaxInt32
+
+// Top limits a query to its first rows with SELECT TOP n. Unlike LIMIT,
+// TOP is accepted by HANA in subqueries, e.g. when used with IN:
+//
+//	db.Where("id IN (?)", db.Model(&User{}).Select("id").Clauses(hdb.Top{Limit: 10}))
+type Top struct {
+	Limit int
+}
+
+// Name top is stored as LIMIT clause, so that Limit and Top replace each other
+func (top Top) Name() string {
+	return ClauseLimit
+}
+
+// Build top is rendered by the SELECT clause builder
+func (top Top) Build(builder clause.Builder) {
+}
+
+// MergeClause merge top clause
+func (top Top) MergeClause(c *clause.Clause) {
+	c.Name = ""
+	c.Expression = top
+}
+
+// buildLimit writes limit as HANA LIMIT clause, a zero or negative
+// limit (e.g. Limit(-1)) means no limit
+func buildLimit(limit clause.Limit, builder clause.Builder) {
+	switch {
+	case limit.Limit > 0:
+		builder.WriteString("LIMIT ")
+		builder.WriteString(strconv.Itoa(limit.Limit))
+	case limit.Offset > 0:
+		builder.WriteString("LIMIT ")
+		builder.WriteString(strconv.Itoa(maxLimit))
+	default:
+		return
+	}
+
+	if limit.Offset > 0 {
+		builder.WriteString(" OFFSET ")
+		builder.WriteString(strconv.Itoa(limit.Offset))
+	}
+}
@@ -0,0 +1,38 @@
+package hdb
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestLimit(t *testing.T) {
+	type User struct {
+		ID   uint
+		Name string
+	}
+
+	db := newDryRunDB(t)
+	tests := []struct {
+		Stmt   *gorm.Statement
+		Result string
+	}{
+		{db.Limit(10).Find(&[]User{}).Statement, `SELECT * FROM "users" LIMIT 10`},
+		{db.Limit(10).Offset(20).Find(&[]User{}).Statement, `SELECT * FROM "users" LIMIT 10 OFFSET 20`},
+		{db.Offset(20).Find(&[]User{}).Statement, `SELECT * FROM "users" LIMIT 2147483647 OFFSET 20`},
+		{db.Limit(10).Limit(-1).Find(&[]User{}).Statement, `SELECT * FROM "users"`},
+		{db.Limit(10).Offset(5).Limit(-1).Find(&[]User{}).Statement, `SELECT * FROM "users" LIMIT 2147483647 OFFSET 5`},
+		{db.Clauses(Top{Limit: 3}).Find(&[]User{}).Statement, `SELECT TOP 3 * FROM "users"`},
+		{db.Distinct("name").Clauses(Top{Limit: 3}).Find(&[]User{}).Statement, `SELECT TOP 3 DISTINCT "name" FROM "users"`},
+		{
+			db.Where("id IN (?)", db.Model(&User{}).Select("id").Order("name").Clauses(Top{Limit: 5})).Find(&[]User{}).Statement,
+			`SELECT * FROM "users" WHERE id IN (SELECT TOP 5 "id" FROM "users" ORDER BY name )`,
+		},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.Result, strings.TrimSpace(test.Stmt.SQL.String()))
+	}
+}
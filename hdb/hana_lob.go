@@ -0,0 +1,195 @@
+package hdb
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/callbacks"
+	"gorm.io/gorm/schema"
+)
+
+const (
+	// DataTypeBlob binary large object
+	DataTypeBlob schema.DataType = "blob"
+	// DataTypeNClob unicode character large object
+	DataTypeNClob schema.DataType = "nclob"
+)
+
+// Lob is a BLOB model field, which is streamed by the driver in chunks of the
+// connector's lob chunk size instead of being held in memory.
+//
+// On create and update the content is read from Reader, a nil Reader writes NULL.
+// When a single struct is queried (First, Take, Find(&model)) with RegisterCallbacks,
+// the content is written into Writer. Without a Writer the content is buffered
+// in memory and can be read from Reader afterwards.
+type Lob struct {
+	Reader io.Reader
+	Writer io.Writer
+	// buffered is set if the content was scanned into an in-memory buffer
+	buffered bool
+}
+
+// NLob is a NCLOB model field, see Lob
+type NLob struct {
+	Lob
+}
+
+type lobHolder interface {
+	lob() *Lob
+}
+
+func (l *Lob) lob() *Lob {
+	return l
+}
+
+// GormDataType gorm common data type
+func (Lob) GormDataType() string {
+	return string(DataTypeBlob)
+}
+
+// GormDataType gorm common data type
+func (NLob) GormDataType() string {
+	return string(DataTypeNClob)
+}
+
+// Scan implements the database/sql/Scanner interface
+func (l *Lob) Scan(src interface{}) error {
+	// scan values are pooled by gorm, never append to a previous buffer
+	if l.buffered {
+		*l = Lob{}
+	}
+
+	if src == nil {
+		return nil
+	}
+
+	if l.Writer == nil {
+		buffer := &bytes.Buffer{}
+		*l = Lob{Reader: buffer, Writer: buffer, buffered: true}
+	}
+
+	switch src := src.(type) {
+	case interface{ SetWriter(w io.Writer) error }:
+		return src.SetWriter(l.Writer)
+	case []byte:
+		_, err := l.Writer.Write(src)
+		return err
+	case string:
+		_, err := io.WriteString(l.Writer, src)
+		return err
+	}
+
+	return fmt.Errorf("lob: invalid scan type %T", src)
+}
+
+// Value implements the database/sql/Valuer interface
+func (l Lob) Value() (driver.Value, error) {
+	if l.Reader == nil {
+		return nil, nil
+	}
+	return l.Reader, nil
+}
+
+// lobScanTargets returns the lobs of the queried struct which have a writer
+func lobScanTargets(stmt *gorm.Statement) map[*schema.Field]*Lob {
+	if stmt.Schema == nil || !stmt.ReflectValue.IsValid() || stmt.ReflectValue.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var lobs map[*schema.Field]*Lob
+	for _, field := range stmt.Schema.Fields {
+		if _, ok := reflect.New(field.IndirectFieldType).Interface().(lobHolder); !ok {
+			continue
+		}
+
+		value := field.ReflectValueOf(stmt.Context, stmt.ReflectValue)
+		if value.Kind() == reflect.Ptr {
+			if value.IsNil() {
+				continue
+			}
+		} else {
+			value = value.Addr()
+		}
+
+		if lob := value.Interface().(lobHolder).lob(); lob.Writer != nil {
+			if lobs == nil {
+				lobs = map[*schema.Field]*Lob{}
+			}
+			lobs[field] = lob
+		}
+	}
+
+	return lobs
+}
+
+// hanaQueryCallback scans lob columns of a queried struct directly into their writers,
+// all other queries are handled by gorm's query callback
+func hanaQueryCallback(db *gorm.DB) {
+	if db.Error != nil {
+		return
+	}
+
+	lobs := lobScanTargets(db.Statement)
+	if len(lobs) == 0 {
+		callbacks.Query(db)
+		return
+	}
+
+	callbacks.BuildQuerySQL(db)
+	if db.DryRun || db.Error != nil {
+		return
+	}
+
+	rows, err := db.Statement.ConnPool.QueryContext(db.Statement.Context, db.Statement.SQL.String(), db.Statement.Vars...)
+	if err != nil {
+		db.AddError(err)
+		return
+	}
+	defer func() {
+		db.AddError(rows.Close())
+	}()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		db.AddError(err)
+		return
+	}
+
+	db.RowsAffected = 0
+	if rows.Next() {
+		values := make([]interface{}, len(columns))
+		fields := make([]*schema.Field, len(columns))
+
+		for idx, column := range columns {
+			field := db.Statement.Schema.LookUpField(column)
+			switch {
+			case field == nil || !field.Readable:
+				values[idx] = new(interface{})
+			case lobs[field] != nil:
+				values[idx] = lobs[field]
+			default:
+				fields[idx] = field
+				values[idx] = field.NewValuePool.Get()
+			}
+		}
+
+		db.RowsAffected++
+		db.AddError(rows.Scan(values...))
+
+		for idx, field := range fields {
+			if field != nil {
+				db.AddError(field.Set(db.Statement.Context, db.Statement.ReflectValue, values[idx]))
+				field.NewValuePool.Put(values[idx])
+			}
+		}
+	}
+	db.AddError(rows.Err())
+
+	if db.RowsAffected == 0 && db.Statement.RaiseErrorOnNotFound && db.Error == nil {
+		db.AddError(gorm.ErrRecordNotFound)
+	}
+}
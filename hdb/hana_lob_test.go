@@ -0,0 +1,67 @@
+package hdb
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLobDataType(t *testing.T) {
+	type Document struct {
+		ID      uint
+		Content Lob
+		Text    NLob
+		Note    *NLob
+		Legacy  Lob `gorm:"type:clob"`
+		Large   []byte
+		Long    string `gorm:"size:10000"`
+	}
+
+	db := newDryRunDB(t)
+	stmt := db.Model(&Document{}).Statement
+	assert.Nil(t, stmt.Parse(&Document{}))
+
+	m := db.Migrator().(Migrator)
+	for name, dataType := range map[string]string{
+		"Content": "blob",
+		"Text":    "nclob",
+		"Note":    "nclob",
+		"Legacy":  "clob",
+		"Large":   "blob",
+		"Long":    "nclob",
+	} {
+		assert.Equal(t, dataType, m.FullDataTypeOf(stmt.Schema.LookUpField(name)).SQL, name)
+	}
+}
+
+func TestLobScan(t *testing.T) {
+	var buffer bytes.Buffer
+	lob := Lob{Writer: &buffer}
+	assert.Nil(t, lob.Scan([]byte("streamed")))
+	assert.Equal(t, "streamed", buffer.String())
+
+	// without writer the content is buffered
+	pooled := Lob{}
+	assert.Nil(t, pooled.Scan("first"))
+	first := pooled
+	assert.Nil(t, pooled.Scan("second"))
+	content, err := ioutil.ReadAll(pooled.Reader)
+	assert.Nil(t, err)
+	assert.Equal(t, "second", string(content))
+	content, err = ioutil.ReadAll(first.Reader)
+	assert.Nil(t, err)
+	assert.Equal(t, "first", string(content))
+
+	assert.Nil(t, pooled.Scan(nil))
+	assert.Nil(t, pooled.Reader)
+
+	value, err := Lob{Reader: strings.NewReader("x")}.Value()
+	assert.Nil(t, err)
+	assert.NotNil(t, value)
+	value, err = Lob{}.Value()
+	assert.Nil(t, err)
+	assert.Nil(t, value)
+}
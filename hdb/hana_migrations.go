@@ -0,0 +1,532 @@
+package hdb
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DefaultMigrationTable is the history table of Migrations without Table
+const DefaultMigrationTable = "MIGRATION_HISTORY"
+
+// ErrMigrationChanged is returned if an applied migration differs from the recorded checksum
+var ErrMigrationChanged = errors.New("migration: applied migration changed")
+
+// Migration is a numbered schema change, which is applied in a transaction.
+// DDL is part of the transaction, so failed migrations leave no changes.
+type Migration struct {
+	Version     int64
+	Description string
+	// Up and Down change the schema, they are used instead of UpSQL and DownSQL
+	Up   func(tx *gorm.DB) error
+	Down func(tx *gorm.DB) error
+	// UpSQL and DownSQL are statements separated by semicolons, SQLScript
+	// bodies within BEGIN ... END are kept together
+	UpSQL   string
+	DownSQL string
+}
+
+// Checksum identifies the version of the migration in the history, Go
+// migrations are identified by version and description only
+func (m Migration) Checksum() string {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%d\x00%s\x00%s", m.Version, m.Description, m.UpSQL)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func (m Migration) reversible() bool {
+	return m.Down != nil || strings.TrimSpace(m.DownSQL) != ""
+}
+
+var (
+	migrationsMu         sync.Mutex
+	registeredMigrations []Migration
+)
+
+// RegisterMigration registers a migration applied by Migrations without
+// explicit migrations, it panics if the version is invalid or registered twice
+func RegisterMigration(migration Migration) {
+	migrationsMu.Lock()
+	defer migrationsMu.Unlock()
+
+	if migration.Version <= 0 {
+		panic(fmt.Sprintf("migration: invalid version %d", migration.Version))
+	}
+	for _, m := range registeredMigrations {
+		if m.Version == migration.Version {
+			panic(fmt.Sprintf("migration: version %d registered twice", migration.Version))
+		}
+	}
+	registeredMigrations = append(registeredMigrations, migration)
+}
+
+// RegisteredMigrations returns the registered migrations ordered by version
+func RegisteredMigrations() []Migration {
+	migrationsMu.Lock()
+	defer migrationsMu.Unlock()
+
+	migrations := append([]Migration(nil), registeredMigrations...)
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations
+}
+
+var migrationFileRegexp = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// LoadMigrations reads the SQL migrations of dir, which are named like
+// 0001_create_orders.up.sql and 0001_create_orders.down.sql
+func LoadMigrations(dir string) ([]Migration, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*Migration{}
+	var migrations []*Migration
+	for _, file := range files {
+		match := migrationFileRegexp.FindStringSubmatch(file.Name())
+		if file.IsDir() || match == nil {
+			continue
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration: invalid version of %s", file.Name())
+		}
+		description := strings.ReplaceAll(match[2], "_", " ")
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Description: description}
+			byVersion[version] = migration
+			migrations = append(migrations, migration)
+		} else if migration.Description != description {
+			return nil, fmt.Errorf("migration: version %d used for %q and %q", version, migration.Description, description)
+		}
+
+		content, err := ioutil.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if match[3] == "up" {
+			migration.UpSQL = string(content)
+		} else {
+			migration.DownSQL = string(content)
+		}
+	}
+
+	result := make([]Migration, 0, len(migrations))
+	for _, migration := range migrations {
+		if migration.UpSQL == "" {
+			return nil, fmt.Errorf("migration: version %d has no up script", migration.Version)
+		}
+		result = append(result, *migration)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Version < result[j].Version })
+	return result, nil
+}
+
+// splitStatements splits a script at the semicolons outside of literals,
+// quoted identifiers, comments and the BEGIN ... END blocks of SQLScript
+// bodies like CREATE PROCEDURE p AS BEGIN ... END
+func splitStatements(script string) []string {
+	var (
+		statements []string
+		start      int
+		// depth is the nesting of BEGIN ... END blocks and CASE ... END expressions
+		depth int
+	)
+	appendStatement := func(end int) {
+		if stmt := strings.TrimSpace(script[start:end]); stmt != "" {
+			statements = append(statements, stmt)
+		}
+		start = end + 1
+	}
+
+	for i := 0; i < len(script); i++ {
+		switch c := script[i]; {
+		case c == '\'' || c == '"':
+			// doubled quotes are read as two literals
+			if end := strings.IndexByte(script[i+1:], c); end >= 0 {
+				i += end + 1
+			} else {
+				i = len(script)
+			}
+		case c == '-' && strings.HasPrefix(script[i:], "--"):
+			if end := strings.IndexByte(script[i:], '\n'); end >= 0 {
+				i += end
+			} else {
+				i = len(script)
+			}
+		case c == '/' && strings.HasPrefix(script[i:], "/*"):
+			if end := strings.Index(script[i+2:], "*/"); end >= 0 {
+				i += end + 3
+			} else {
+				i = len(script)
+			}
+		case isWordStart(c):
+			word, end := scanWord(script, i)
+			switch word {
+			case "BEGIN", "CASE":
+				depth++
+			case "END":
+				// END IF, END FOR, END WHILE and END LOOP close no block
+				next, nextEnd := scanWord(script, skipSpace(script, end))
+				switch next {
+				case "IF", "FOR", "WHILE", "LOOP":
+					end = nextEnd
+				case "CASE":
+					end = nextEnd
+					depth--
+				default:
+					depth--
+				}
+				if depth < 0 {
+					depth = 0
+				}
+			}
+			i = end - 1
+		case c == ';' && depth == 0:
+			appendStatement(i)
+		}
+	}
+	appendStatement(len(script))
+	return statements
+}
+
+func isWordStart(c byte) bool {
+	return c == '_' || (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z')
+}
+
+// scanWord returns the upper case identifier starting at i and its end
+func scanWord(script string, i int) (string, int) {
+	if i >= len(script) || !isWordStart(script[i]) {
+		return "", i
+	}
+	end := i + 1
+	for end < len(script) {
+		c := script[end]
+		if !isWordStart(c) && !(c >= '0' && c <= '9') && c != '$' && c != '#' {
+			break
+		}
+		end++
+	}
+	return strings.ToUpper(script[i:end]), end
+}
+
+func skipSpace(script string, i int) int {
+	for i < len(script) && strings.IndexByte(" \t\r\n", script[i]) >= 0 {
+		i++
+	}
+	return i
+}
+
+// migrationRecord is a row of the history table
+type migrationRecord struct {
+	Version     int64     `gorm:"column:VERSION;primaryKey;autoIncrement:false"`
+	Description string    `gorm:"column:DESCRIPTION;size:256"`
+	Checksum    string    `gorm:"column:CHECKSUM;size:64"`
+	AppliedAt   time.Time `gorm:"column:APPLIED_AT"`
+	Baseline    bool      `gorm:"column:BASELINE"`
+}
+
+// MigrationStatus is the state of a migration
+type MigrationStatus struct {
+	Version     int64
+	Description string
+	// Applied is the time the migration was applied or baselined, zero for pending migrations
+	Applied time.Time
+	// Baseline reports the baseline version and the versions below, which were not applied
+	Baseline bool
+	// Changed reports a checksum different from the recorded one
+	Changed bool
+	// Missing reports applied migrations which are unknown
+	Missing bool
+}
+
+// Pending reports migrations which are not applied yet
+func (s MigrationStatus) Pending() bool {
+	return s.Applied.IsZero()
+}
+
+// Migrations applies versioned migrations in order and records them in a
+// history table:
+//
+//	migrations := hdb.Migrations{DB: db}
+//	err := migrations.Up()
+//
+// Runners lock the history table while they change the schema, so
+// concurrent runners apply every migration once.
+type Migrations struct {
+	DB *gorm.DB
+	// Table is the history table, defaults to DefaultMigrationTable
+	Table string
+	// Migrations are the known migrations, defaults to the registered migrations
+	Migrations []Migration
+}
+
+func (m *Migrations) table() string {
+	if m.Table == "" {
+		return DefaultMigrationTable
+	}
+	return m.Table
+}
+
+// migrations returns the migrations ordered by version
+func (m *Migrations) migrations() ([]Migration, error) {
+	migrations := m.Migrations
+	if migrations == nil {
+		return RegisteredMigrations(), nil
+	}
+
+	migrations = append([]Migration(nil), migrations...)
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	for i, migration := range migrations {
+		if migration.Version <= 0 {
+			return nil, fmt.Errorf("migration: invalid version %d", migration.Version)
+		}
+		if i > 0 && migrations[i-1].Version == migration.Version {
+			return nil, fmt.Errorf("migration: version %d defined twice", migration.Version)
+		}
+	}
+	return migrations, nil
+}
+
+// createTable creates the history table unless it exists
+func (m *Migrations) createTable(db *gorm.DB) error {
+	migrator := db.Table(m.table()).Migrator()
+	if migrator.HasTable(m.table()) {
+		return nil
+	}
+	if err := migrator.CreateTable(&migrationRecord{}); err != nil && !migrator.HasTable(m.table()) {
+		return err
+	}
+	return nil
+}
+
+func (m *Migrations) history(tx *gorm.DB) ([]migrationRecord, error) {
+	var records []migrationRecord
+	err := tx.Table(m.table()).Order("VERSION").Find(&records).Error
+	return records, err
+}
+
+// transaction runs fc in a transaction, which locks the history table and
+// includes DDL statements
+func (m *Migrations) transaction(db *gorm.DB, fc func(tx *gorm.DB, history []migrationRecord) error) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("LOCK TABLE ? IN EXCLUSIVE MODE", clause.Table{Name: m.table()}).Error; err != nil {
+			return err
+		}
+		history, err := m.history(tx)
+		if err != nil {
+			return err
+		}
+		return fc(tx, history)
+	})
+}
+
+// connection runs fc on a connection which does not commit DDL statements implicitly
+func (m *Migrations) connection(fc func(db *gorm.DB) error) error {
+	return m.DB.Connection(func(db *gorm.DB) error {
+		if err := m.createTable(db); err != nil {
+			return err
+		}
+		if err := db.Exec("SET TRANSACTION AUTOCOMMIT DDL OFF").Error; err != nil {
+			return err
+		}
+		defer db.Exec("SET TRANSACTION AUTOCOMMIT DDL ON")
+		return fc(db)
+	})
+}
+
+// Up applies all pending migrations
+func (m *Migrations) Up() error {
+	return m.UpTo(0)
+}
+
+// UpTo applies the pending migrations up to version, all migrations if version is 0
+func (m *Migrations) UpTo(version int64) error {
+	migrations, err := m.migrations()
+	if err != nil {
+		return err
+	}
+
+	return m.connection(func(db *gorm.DB) error {
+		for _, migration := range migrations {
+			if version > 0 && migration.Version > version {
+				break
+			}
+			if err := m.transaction(db, func(tx *gorm.DB, history []migrationRecord) error {
+				return m.apply(tx, history, migration)
+			}); err != nil {
+				return fmt.Errorf("migration %d %s: %w", migration.Version, migration.Description, err)
+			}
+		}
+		return nil
+	})
+}
+
+// apply applies the migration unless the history contains it
+func (m *Migrations) apply(tx *gorm.DB, history []migrationRecord, migration Migration) error {
+	var latest int64
+	for _, record := range history {
+		if record.Version == migration.Version {
+			if !record.Baseline && record.Checksum != migration.Checksum() {
+				return ErrMigrationChanged
+			}
+			return nil
+		}
+		if record.Version > latest {
+			latest = record.Version
+		}
+	}
+	if len(history) > 0 && history[0].Baseline && migration.Version < history[0].Version {
+		return nil
+	}
+	if migration.Version < latest {
+		return fmt.Errorf("migration: version %d is older than the applied version %d", migration.Version, latest)
+	}
+
+	if migration.Up != nil {
+		if err := migration.Up(tx); err != nil {
+			return err
+		}
+	} else {
+		for _, stmt := range splitStatements(migration.UpSQL) {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Table(m.table()).Create(&migrationRecord{
+		Version:     migration.Version,
+		Description: migration.Description,
+		Checksum:    migration.Checksum(),
+		AppliedAt:   tx.NowFunc(),
+	}).Error
+}
+
+// Down reverts the latest applied migration
+func (m *Migrations) Down() error {
+	migrations, err := m.migrations()
+	if err != nil {
+		return err
+	}
+
+	return m.connection(func(db *gorm.DB) error {
+		return m.transaction(db, func(tx *gorm.DB, history []migrationRecord) error {
+			if len(history) == 0 || history[len(history)-1].Baseline {
+				return errors.New("migration: no applied migration to revert")
+			}
+			record := history[len(history)-1]
+
+			var migration *Migration
+			for i := range migrations {
+				if migrations[i].Version == record.Version {
+					migration = &migrations[i]
+				}
+			}
+			switch {
+			case migration == nil:
+				return fmt.Errorf("migration: applied version %d is unknown", record.Version)
+			case !migration.reversible():
+				return fmt.Errorf("migration: version %d is irreversible", record.Version)
+			}
+
+			if migration.Down != nil {
+				if err := migration.Down(tx); err != nil {
+					return err
+				}
+			} else {
+				for _, stmt := range splitStatements(migration.DownSQL) {
+					if err := tx.Exec(stmt).Error; err != nil {
+						return err
+					}
+				}
+			}
+			return tx.Table(m.table()).Delete(&migrationRecord{Version: record.Version}).Error
+		})
+	})
+}
+
+// Baseline marks an existing schema as migrated up to version, the
+// migrations up to version are not applied. The history has to be empty.
+func (m *Migrations) Baseline(version int64) error {
+	if version <= 0 {
+		return fmt.Errorf("migration: invalid version %d", version)
+	}
+
+	return m.connection(func(db *gorm.DB) error {
+		return m.transaction(db, func(tx *gorm.DB, history []migrationRecord) error {
+			if len(history) > 0 {
+				return errors.New("migration: baseline of a schema with migration history")
+			}
+			return tx.Table(m.table()).Create(&migrationRecord{
+				Version:     version,
+				Description: "baseline",
+				AppliedAt:   tx.NowFunc(),
+				Baseline:    true,
+			}).Error
+		})
+	})
+}
+
+// Status returns the state of the known and the applied migrations ordered by version
+func (m *Migrations) Status() ([]MigrationStatus, error) {
+	migrations, err := m.migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	var history []migrationRecord
+	if m.DB.Migrator().HasTable(m.table()) {
+		if history, err = m.history(m.DB); err != nil {
+			return nil, err
+		}
+	}
+
+	var statuses []MigrationStatus
+	for _, migration := range migrations {
+		status := MigrationStatus{Version: migration.Version, Description: migration.Description}
+		if len(history) > 0 && history[0].Baseline && migration.Version < history[0].Version {
+			status.Applied, status.Baseline = history[0].AppliedAt, true
+		}
+		for _, record := range history {
+			if record.Version == migration.Version {
+				status.Applied = record.AppliedAt
+				status.Baseline = record.Baseline
+				status.Changed = !record.Baseline && record.Checksum != migration.Checksum()
+			}
+		}
+		statuses = append(statuses, status)
+	}
+
+	for _, record := range history {
+		known := false
+		for _, migration := range migrations {
+			known = known || migration.Version == record.Version
+		}
+		if !known {
+			statuses = append(statuses, MigrationStatus{
+				Version:     record.Version,
+				Description: record.Description,
+				Applied:     record.AppliedAt,
+				Baseline:    record.Baseline,
+				Missing:     !record.Baseline,
+			})
+		}
+	}
+	sort.SliceStable(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
@@ -0,0 +1,330 @@
+package hdb
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// historyConnector is a database holding a migration history table only,
+// other statements are recorded and fail if they contain FAIL
+type historyConnector struct {
+	mu         sync.Mutex
+	created    bool
+	records    [][]driver.Value
+	statements []string
+}
+
+var (
+	historyCountRegexp  = regexp.MustCompile(`(?i)^SELECT count\(1\) FROM sys\.tables`)
+	historySchemaRegexp = regexp.MustCompile(`^SELECT CURRENT_SCHEMA FROM DUMMY$`)
+	historySelectRegexp = regexp.MustCompile(`^SELECT \* FROM "MIGRATION_HISTORY" ORDER BY VERSION$`)
+	historyCreateRegexp = regexp.MustCompile(`^CREATE TABLE "MIGRATION_HISTORY" `)
+	historyInsertRegexp = regexp.MustCompile(`^INSERT INTO "MIGRATION_HISTORY" `)
+	historyDeleteRegexp = regexp.MustCompile(`^DELETE FROM "MIGRATION_HISTORY" WHERE "MIGRATION_HISTORY"\."VERSION" = \?$`)
+)
+
+func (c *historyConnector) Connect(context.Context) (driver.Conn, error) {
+	return &historyConn{c: c}, nil
+}
+func (c *historyConnector) Driver() driver.Driver            { return c }
+func (c *historyConnector) Open(string) (driver.Conn, error) { return &historyConn{c: c}, nil }
+
+func (c *historyConnector) versions() []int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var versions []int64
+	for _, record := range c.records {
+		versions = append(versions, record[0].(int64))
+	}
+	return versions
+}
+
+type historyConn struct {
+	c      *historyConnector
+	backup [][]driver.Value
+}
+
+func (conn *historyConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("unsupported") }
+func (conn *historyConn) Close() error                        { return nil }
+
+func (conn *historyConn) Begin() (driver.Tx, error) {
+	conn.c.mu.Lock()
+	defer conn.c.mu.Unlock()
+	conn.backup = append([][]driver.Value(nil), conn.c.records...)
+	return conn, nil
+}
+
+func (conn *historyConn) Commit() error { return nil }
+
+func (conn *historyConn) Rollback() error {
+	conn.c.mu.Lock()
+	defer conn.c.mu.Unlock()
+	conn.c.records = conn.backup
+	return nil
+}
+
+func (conn *historyConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	conn.c.mu.Lock()
+	defer conn.c.mu.Unlock()
+
+	switch {
+	case historySchemaRegexp.MatchString(query):
+		return &historyRows{columns: []string{"CURRENT_SCHEMA"}, rows: [][]driver.Value{{"SALES"}}}, nil
+	case historyCountRegexp.MatchString(query):
+		count := int64(0)
+		if conn.c.created && args[1].Value == DefaultMigrationTable {
+			count = 1
+		}
+		return &historyRows{columns: []string{"COUNT"}, rows: [][]driver.Value{{count}}}, nil
+	case historySelectRegexp.MatchString(query):
+		return &historyRows{
+			columns: []string{"VERSION", "DESCRIPTION", "CHECKSUM", "APPLIED_AT", "BASELINE"},
+			rows:    append([][]driver.Value(nil), conn.c.records...),
+		}, nil
+	}
+	return nil, fmt.Errorf("unsupported query %q", query)
+}
+
+func (conn *historyConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	conn.c.mu.Lock()
+	defer conn.c.mu.Unlock()
+
+	switch {
+	case historyCreateRegexp.MatchString(query):
+		conn.c.created = true
+	case historyInsertRegexp.MatchString(query):
+		record := make([]driver.Value, len(args))
+		for i, arg := range args {
+			record[i] = arg.Value
+		}
+		conn.c.records = append(conn.c.records, record)
+	case historyDeleteRegexp.MatchString(query):
+		for i, record := range conn.c.records {
+			if record[0] == args[0].Value {
+				conn.c.records = append(conn.c.records[:i:i], conn.c.records[i+1:]...)
+				break
+			}
+		}
+	case strings.Contains(query, "FAIL"):
+		return nil, errors.New("statement failed")
+	default:
+		conn.c.statements = append(conn.c.statements, query)
+	}
+	return driver.RowsAffected(1), nil
+}
+
+type historyRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *historyRows) Columns() []string { return r.columns }
+func (r *historyRows) Close() error      { return nil }
+
+func (r *historyRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func newMigrationsDB(t *testing.T) (*gorm.DB, *historyConnector) {
+	connector := &historyConnector{}
+	db, err := gorm.Open(New(Config{Connector: connector}), &gorm.Config{
+		Logger:  logger.Discard,
+		NowFunc: func() time.Time { return goldenTime },
+	})
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	return db, connector
+}
+
+var testMigrations = []Migration{
+	{
+		Version:     2,
+		Description: "create items",
+		UpSQL:       "CREATE TABLE ITEMS (ID INTEGER, NOTE NVARCHAR(10) DEFAULT ';');\nCREATE INDEX IDX_ITEMS ON ITEMS (ID);",
+		DownSQL:     "DROP TABLE ITEMS",
+	},
+	{
+		Version:     1,
+		Description: "create orders",
+		Up: func(tx *gorm.DB) error {
+			return tx.Exec("CREATE TABLE ORDERS (ID INTEGER)").Error
+		},
+	},
+}
+
+func TestMigrationsUpDown(t *testing.T) {
+	db, connector := newMigrationsDB(t)
+	migrations := Migrations{DB: db, Migrations: testMigrations}
+
+	assert.Nil(t, migrations.Up())
+	assert.Equal(t, []int64{1, 2}, connector.versions())
+	assert.Equal(t, []string{
+		"SET TRANSACTION AUTOCOMMIT DDL OFF",
+		`LOCK TABLE "MIGRATION_HISTORY" IN EXCLUSIVE MODE`,
+		"CREATE TABLE ORDERS (ID INTEGER)",
+		`LOCK TABLE "MIGRATION_HISTORY" IN EXCLUSIVE MODE`,
+		"CREATE TABLE ITEMS (ID INTEGER, NOTE NVARCHAR(10) DEFAULT ';')",
+		"CREATE INDEX IDX_ITEMS ON ITEMS (ID)",
+		"SET TRANSACTION AUTOCOMMIT DDL ON",
+	}, connector.statements)
+
+	// applied migrations are skipped
+	connector.statements = nil
+	assert.Nil(t, migrations.Up())
+	assert.Len(t, connector.statements, 4)
+
+	statuses, err := migrations.Status()
+	assert.Nil(t, err)
+	assert.Equal(t, []MigrationStatus{
+		{Version: 1, Description: "create orders", Applied: goldenTime},
+		{Version: 2, Description: "create items", Applied: goldenTime},
+	}, statuses)
+
+	connector.statements = nil
+	assert.Nil(t, migrations.Down())
+	assert.Equal(t, []int64{1}, connector.versions())
+	assert.Contains(t, connector.statements, "DROP TABLE ITEMS")
+
+	assert.EqualError(t, migrations.Down(), "migration: version 1 is irreversible")
+	assert.Equal(t, []int64{1}, connector.versions())
+}
+
+func TestMigrationsFailure(t *testing.T) {
+	db, connector := newMigrationsDB(t)
+	migrations := Migrations{DB: db, Migrations: append([]Migration{
+		{Version: 3, Description: "broken", UpSQL: "CREATE TABLE FAIL (ID INTEGER)"},
+	}, testMigrations...)}
+
+	err := migrations.Up()
+	assert.EqualError(t, err, "migration 3 broken: statement failed")
+	assert.Equal(t, []int64{1, 2}, connector.versions())
+
+	// changed scripts of applied migrations are rejected
+	migrations.Migrations = []Migration{{Version: 1, Description: "create orders", UpSQL: "CREATE TABLE ORDERS (ID BIGINT)"}}
+	assert.True(t, errors.Is(migrations.Up(), ErrMigrationChanged))
+
+	statuses, err := migrations.Status()
+	assert.Nil(t, err)
+	assert.Equal(t, []MigrationStatus{
+		{Version: 1, Description: "create orders", Applied: goldenTime, Changed: true},
+		{Version: 2, Description: "create items", Applied: goldenTime, Missing: true},
+	}, statuses)
+
+	migrations.Migrations = []Migration{testMigrations[1], testMigrations[1]}
+	assert.EqualError(t, migrations.Up(), "migration: version 1 defined twice")
+
+	// migrations older than the applied ones are rejected
+	db, _ = newMigrationsDB(t)
+	migrations = Migrations{DB: db, Migrations: []Migration{
+		testMigrations[1], {Version: 3, Description: "add note", UpSQL: "ALTER TABLE ITEMS ADD (TEXT NCLOB)"},
+	}}
+	assert.Nil(t, migrations.Up())
+	migrations.Migrations = append(migrations.Migrations, testMigrations[0])
+	assert.EqualError(t, migrations.Up(), "migration 2 create items: migration: version 2 is older than the applied version 3")
+}
+
+func TestMigrationsBaseline(t *testing.T) {
+	db, connector := newMigrationsDB(t)
+	migrations := Migrations{DB: db, Migrations: append([]Migration{{
+		Version: 3, Description: "add note",
+		UpSQL: "ALTER TABLE ITEMS ADD (TEXT NCLOB)", DownSQL: "ALTER TABLE ITEMS DROP (TEXT)",
+	}}, testMigrations...)}
+
+	statuses, err := migrations.Status()
+	assert.Nil(t, err)
+	assert.Len(t, statuses, 3)
+	assert.True(t, statuses[0].Pending())
+
+	assert.Nil(t, migrations.Baseline(2))
+	assert.NotNil(t, migrations.Baseline(2))
+
+	connector.statements = nil
+	assert.Nil(t, migrations.UpTo(3))
+	assert.Equal(t, []int64{2, 3}, connector.versions())
+	assert.Contains(t, connector.statements, "ALTER TABLE ITEMS ADD (TEXT NCLOB)")
+	assert.NotContains(t, connector.statements, "CREATE TABLE ORDERS (ID INTEGER)")
+
+	statuses, err = migrations.Status()
+	assert.Nil(t, err)
+	assert.Equal(t, []MigrationStatus{
+		{Version: 1, Description: "create orders", Applied: goldenTime, Baseline: true},
+		{Version: 2, Description: "create items", Applied: goldenTime, Baseline: true},
+		{Version: 3, Description: "add note", Applied: goldenTime},
+	}, statuses)
+
+	assert.Nil(t, migrations.Down())
+	assert.EqualError(t, migrations.Down(), "migration: no applied migration to revert")
+}
+
+func TestLoadMigrations(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"0002_add_note.up.sql":         "ALTER TABLE ITEMS ADD (NOTE NCLOB)",
+		"0001_create_items.up.sql":     "CREATE TABLE ITEMS (ID INTEGER)",
+		"0001_create_items.down.sql":   "DROP TABLE ITEMS",
+		"README.md":                    "migrations",
+		"0003_missing_up.down.sql.bak": "",
+	} {
+		assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+
+	migrations, err := LoadMigrations(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, []Migration{
+		{Version: 1, Description: "create items", UpSQL: "CREATE TABLE ITEMS (ID INTEGER)", DownSQL: "DROP TABLE ITEMS"},
+		{Version: 2, Description: "add note", UpSQL: "ALTER TABLE ITEMS ADD (NOTE NCLOB)"},
+	}, migrations)
+
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "0003_drop_items.down.sql"), []byte("DROP TABLE ITEMS"), 0644))
+	_, err = LoadMigrations(dir)
+	assert.EqualError(t, err, "migration: version 3 has no up script")
+}
+
+func TestSplitStatements(t *testing.T) {
+	assert.Equal(t, []string{
+		"INSERT INTO T VALUES ('a;b', 'it''s')",
+		`CREATE TABLE "x;y" (ID INTEGER)`,
+		"-- comment; with semicolon\nDELETE FROM T",
+		"/* ; */ SELECT 1 FROM DUMMY",
+	}, splitStatements("INSERT INTO T VALUES ('a;b', 'it''s');\n"+
+		`CREATE TABLE "x;y" (ID INTEGER);`+
+		"\n-- comment; with semicolon\nDELETE FROM T;\n/* ; */ SELECT 1 FROM DUMMY;\n\n"))
+
+	// SQLScript bodies are single statements
+	procedure := "CREATE PROCEDURE ARCHIVE_ORDERS (IN days INTEGER) AS\n" +
+		"BEGIN\n" +
+		"  DECLARE n INTEGER;\n" +
+		"  DECLARE EXIT HANDLER FOR SQLEXCEPTION BEGIN RESIGNAL; END;\n" +
+		"  SELECT COUNT(*) INTO n FROM ORDERS WHERE STATE = CASE WHEN :days > 0 THEN 'done' ELSE 'open' END;\n" +
+		"  IF :n > 0 THEN\n" +
+		"    FOR i IN 1..:n DO\n" +
+		"      DELETE FROM ORDERS WHERE ID = :i;\n" +
+		"    END FOR;\n" +
+		"  END IF;\n" +
+		"  CASE WHEN :n = 0 THEN RETURN; END CASE;\n" +
+		"END"
+	trigger := "CREATE TRIGGER ORDERS_AUDIT AFTER INSERT ON ORDERS FOR EACH ROW\n" +
+		"BEGIN\n  INSERT INTO AUDIT VALUES ('end;');\nEND"
+	assert.Equal(t, []string{procedure, trigger, "DROP TABLE T"},
+		splitStatements(procedure+";\n"+trigger+";\nDROP TABLE T;\n"))
+}
@@ -1,7 +1,9 @@
 package hdb
 
 import (
+	"database/sql"
 	"fmt"
+	"strings"
 
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
@@ -48,7 +50,7 @@ func (m Migrator) AlterColumn(value interface{}, field string) error {
 		if field := stmt.Schema.LookUpField(field); field != nil {
 			return m.DB.Exec(
 				"ALTER TABLE ? ALTER (? ?)",
-				clause.Table{Name: stmt.Table},
+				m.CurrentTable(stmt),
 				clause.Column{Name: field.DBName},
 				m.FullDataTypeOf(field),
 			).Error
@@ -57,12 +59,38 @@ func (m Migrator) AlterColumn(value interface{}, field string) error {
 	})
 }
 
+// tableName returns the table of stmt with its schema, which gorm keeps
+// in the table expression only for tables like sales.orders
+func (m Migrator) tableName(stmt *gorm.Statement) string {
+	if stmt.TableExpr != nil && len(stmt.TableExpr.Vars) == 0 {
+		if parts := splitIdentifier(stmt.TableExpr.SQL); len(parts) == 2 && parts[1] == stmt.Table {
+			return stmt.TableExpr.SQL
+		}
+	}
+	return stmt.Table
+}
+
+// schemaAndTable returns the catalog schema and table name of table,
+// tables without explicit schema are looked up in the current schema
+func (m Migrator) schemaAndTable(table string) (string, string) {
+	if parts := splitIdentifier(table); len(parts) == 2 {
+		return parts[0], parts[1]
+	}
+	return m.DB.Migrator().CurrentDatabase(), table
+}
+
 func (m Migrator) HasTable(value interface{}) bool {
 	var count int64
 
 	m.RunWithValue(value, func(stmt *gorm.Statement) error {
-		currentDatabase := m.DB.Migrator().CurrentDatabase()
-		return m.DB.Raw("SELECT count(1) FROM sys.tables WHERE schema_name = ? AND table_name = ?", currentDatabase, stmt.Table).Row().Scan(&count)
+		schemaName, tableName := m.schemaAndTable(m.tableName(stmt))
+		if m.CatalogSnapshot != nil {
+			if _, ok := m.CatalogSnapshot.lookup(schemaName, tableName); ok {
+				count = 1
+			}
+			return nil
+		}
+		return m.DB.Raw("SELECT count(1) FROM sys.tables WHERE schema_name = ? AND table_name = ?", schemaName, tableName).Row().Scan(&count)
 	})
 
 	return count > 0
@@ -71,15 +99,77 @@ func (m Migrator) HasTable(value interface{}) bool {
 func (m Migrator) HasColumn(value interface{}, field string) bool {
 	var count int64
 	m.RunWithValue(value, func(stmt *gorm.Statement) error {
-		currentDatabase := m.DB.Migrator().CurrentDatabase()
+		schemaName, tableName := m.schemaAndTable(m.tableName(stmt))
 		name := field
-		if field := stmt.Schema.LookUpField(field); field != nil {
-			name = field.DBName
+		if stmt.Schema != nil {
+			if field := stmt.Schema.LookUpField(field); field != nil {
+				name = field.DBName
+			}
 		}
 
+		if m.CatalogSnapshot != nil {
+			if table, ok := m.CatalogSnapshot.lookup(schemaName, tableName); ok && table.column(name) != nil {
+				count = 1
+			}
+			return nil
+		}
 		return m.DB.Raw(
 			"SELECT count(*) FROM SYS.TABLE_COLUMNS WHERE SCHEMA_NAME = ? AND TABLE_NAME = ? AND COLUMN_NAME = ?",
-			currentDatabase, stmt.Table, name,
+			schemaName, tableName, name,
+		).Row().Scan(&count)
+	})
+
+	return count > 0
+}
+
+func (m Migrator) HasIndex(value interface{}, name string) bool {
+	var count int64
+	m.RunWithValue(value, func(stmt *gorm.Statement) error {
+		schemaName, tableName := m.schemaAndTable(m.tableName(stmt))
+		if idx := stmt.Schema.LookIndex(name); idx != nil {
+			name = idx.Name
+		}
+
+		if m.CatalogSnapshot != nil {
+			if table, ok := m.CatalogSnapshot.lookup(schemaName, tableName); ok && indexOf(table.Indexes, name) >= 0 {
+				count = 1
+			}
+			return nil
+		}
+		return m.DB.Raw(
+			"SELECT count(*) FROM SYS.INDEXES WHERE SCHEMA_NAME = ? AND TABLE_NAME = ? AND INDEX_NAME = ?",
+			schemaName, tableName, name,
+		).Row().Scan(&count)
+	})
+
+	return count > 0
+}
+
+func (m Migrator) HasConstraint(value interface{}, name string) bool {
+	var count int64
+	m.RunWithValue(value, func(stmt *gorm.Statement) error {
+		constraint, chk, table := m.GuessConstraintAndTable(stmt, name)
+		if constraint != nil {
+			name = constraint.Name
+		} else if chk != nil {
+			name = chk.Name
+		}
+
+		if table == stmt.Table {
+			table = m.tableName(stmt)
+		}
+		schemaName, tableName := m.schemaAndTable(table)
+		if m.CatalogSnapshot != nil {
+			if table, ok := m.CatalogSnapshot.lookup(schemaName, tableName); ok && table.hasConstraint(name) {
+				count = 1
+			}
+			return nil
+		}
+		return m.DB.Raw(
+			"SELECT count(*) FROM (SELECT CONSTRAINT_NAME FROM SYS.CONSTRAINTS WHERE SCHEMA_NAME = ? AND TABLE_NAME = ? "+
+				"UNION ALL SELECT CONSTRAINT_NAME FROM SYS.REFERENTIAL_CONSTRAINTS WHERE SCHEMA_NAME = ? AND TABLE_NAME = ?) "+
+				"WHERE CONSTRAINT_NAME = ?",
+			schemaName, tableName, schemaName, tableName, name,
 		).Row().Scan(&count)
 	})
 
@@ -87,66 +177,133 @@ func (m Migrator) HasColumn(value interface{}, field string) bool {
 }
 
 func (m Migrator) CurrentDatabase() (name string) {
+	if m.CatalogSnapshot != nil {
+		return m.CatalogSnapshot.Schema
+	}
 	m.DB.Raw("SELECT CURRENT_SCHEMA FROM DUMMY").Row().Scan(&name)
 	return
 }
 
+// GetTables returns the tables of the current schema
+func (m Migrator) GetTables() (tableList []string, err error) {
+	if m.CatalogSnapshot != nil {
+		for _, t := range m.CatalogSnapshot.Tables {
+			if t.Schema == "" || t.Schema == m.CatalogSnapshot.Schema {
+				tableList = append(tableList, t.Name)
+			}
+		}
+		return tableList, nil
+	}
+	err = m.DB.Raw("SELECT TABLE_NAME FROM SYS.TABLES WHERE SCHEMA_NAME = CURRENT_SCHEMA ORDER BY TABLE_NAME").
+		Scan(&tableList).Error
+	return
+}
+
 func (m Migrator) RenameColumn(value interface{}, oldName, newName string) error {
 	return m.RunWithValue(value, func(stmt *gorm.Statement) error {
+		if stmt.Schema != nil {
+			if f := stmt.Schema.LookUpField(oldName); f != nil {
+				oldName = f.DBName
+			}
 
-		var field *schema.Field
-		if f := stmt.Schema.LookUpField(oldName); f != nil {
-			oldName = f.DBName
-			field = f
+			if f := stmt.Schema.LookUpField(newName); f != nil {
+				newName = f.DBName
+			}
 		}
 
-		if f := stmt.Schema.LookUpField(newName); f != nil {
-			newName = f.DBName
-			field = f
+		return m.DB.Exec(
+			"RENAME COLUMN ?.? TO ?",
+			m.CurrentTable(stmt), clause.Column{Name: oldName}, clause.Column{Name: newName},
+		).Error
+	})
+}
+
+// RenameTable renames a table, the new name has no schema as tables stay in their schema
+func (m Migrator) RenameTable(oldName, newName interface{}) error {
+	var oldTable, newTable interface{}
+	if v, ok := oldName.(string); ok {
+		oldTable = clause.Table{Name: v}
+	} else {
+		stmt := &gorm.Statement{DB: m.DB}
+		if err := stmt.Parse(oldName); err != nil {
+			return err
 		}
+		oldTable = m.CurrentTable(stmt)
+	}
 
-		if field != nil {
-			return m.DB.Exec(
-				"ALTER TABLE ? CHANGE ? ? ?",
-				clause.Table{Name: stmt.Table}, clause.Column{Name: oldName},
-				clause.Column{Name: newName}, m.FullDataTypeOf(field),
-			).Error
+	newTableName, ok := newName.(string)
+	if !ok {
+		stmt := &gorm.Statement{DB: m.DB}
+		if err := stmt.Parse(newName); err != nil {
+			return err
 		}
+		newTableName = stmt.Table
+	}
+	parts := splitIdentifier(newTableName)
+	newTable = clause.Column{Name: parts[len(parts)-1]}
 
-		return fmt.Errorf("failed to look up field with name: %s", newName)
-	})
+	return m.DB.Exec("RENAME TABLE ? TO ?", oldTable, newTable).Error
 }
 
-func (m Migrator) RenameIndex(value interface{}, oldName, newName string) error {
-
+func (m Migrator) DropColumn(value interface{}, name string) error {
 	return m.RunWithValue(value, func(stmt *gorm.Statement) error {
-		err := m.DropIndex(value, oldName)
-		if err != nil {
-			return err
+		if stmt.Schema != nil {
+			if field := stmt.Schema.LookUpField(name); field != nil {
+				name = field.DBName
+			}
 		}
 
-		if idx := stmt.Schema.LookIndex(newName); idx == nil {
-			if idx = stmt.Schema.LookIndex(oldName); idx != nil {
-				opts := m.BuildIndexOptions(idx.Fields, stmt)
-				values := []interface{}{clause.Column{Name: newName}, clause.Table{Name: stmt.Table}, opts}
-
-				createIndexSQL := "CREATE "
-				if idx.Class != "" {
-					createIndexSQL += idx.Class + " "
-				}
-				createIndexSQL += "INDEX ? ON ??"
+		return m.DB.Exec("ALTER TABLE ? DROP (?)", m.CurrentTable(stmt), clause.Column{Name: name}).Error
+	})
+}
 
-				if idx.Type != "" {
-					createIndexSQL += " USING " + idx.Type
-				}
+// indexName returns the name of the index, which belongs to the schema of the table
+func (m Migrator) indexName(stmt *gorm.Statement, name string) clause.Table {
+	if parts := splitIdentifier(m.tableName(stmt)); len(parts) == 2 {
+		return clause.Table{Name: quoteIdentifier(parts[0]) + "." + quoteIdentifier(name)}
+	}
+	return clause.Table{Name: quoteIdentifier(name)}
+}
 
-				return m.DB.Exec(createIndexSQL, values...).Error
+func (m Migrator) CreateIndex(value interface{}, name string) error {
+	return m.RunWithValue(value, func(stmt *gorm.Statement) error {
+		if idx := stmt.Schema.LookIndex(name); idx != nil {
+			opts := m.BuildIndexOptions(idx.Fields, stmt)
+			values := []interface{}{m.indexName(stmt, idx.Name), m.CurrentTable(stmt), opts}
+
+			// HANA index types like BTREE precede INDEX and indexes have no comments
+			createIndexSQL := "CREATE "
+			if idx.Class != "" {
+				createIndexSQL += idx.Class + " "
 			}
+			if idx.Type != "" {
+				createIndexSQL += strings.ToUpper(idx.Type) + " "
+			}
+			createIndexSQL += "INDEX ? ON ??"
+
+			return m.DB.Exec(createIndexSQL, values...).Error
+		}
+
+		return fmt.Errorf("failed to create index with name %s", name)
+	})
+}
+
+func (m Migrator) DropIndex(value interface{}, name string) error {
+	return m.RunWithValue(value, func(stmt *gorm.Statement) error {
+		if idx := stmt.Schema.LookIndex(name); idx != nil {
+			name = idx.Name
 		}
 
-		return m.CreateIndex(value, newName)
+		return m.DB.Exec("DROP INDEX ?", m.indexName(stmt, name)).Error
 	})
+}
 
+func (m Migrator) RenameIndex(value interface{}, oldName, newName string) error {
+	return m.RunWithValue(value, func(stmt *gorm.Statement) error {
+		return m.DB.Exec(
+			"RENAME INDEX ? TO ?", m.indexName(stmt, oldName), clause.Column{Name: newName},
+		).Error
+	})
 }
 
 func (m Migrator) DropTable(values ...interface{}) error {
@@ -154,7 +311,7 @@ func (m Migrator) DropTable(values ...interface{}) error {
 	tx := m.DB.Session(&gorm.Session{})
 	for i := len(values) - 1; i >= 0; i-- {
 		if err := m.RunWithValue(values[i], func(stmt *gorm.Statement) error {
-			return tx.Exec("DROP TABLE IF EXISTS ? CASCADE", clause.Table{Name: stmt.Table}).Error
+			return tx.Exec("DROP TABLE IF EXISTS ? CASCADE", m.CurrentTable(stmt)).Error
 		}); err != nil {
 			return err
 		}
@@ -166,14 +323,13 @@ func (m Migrator) DropConstraint(value interface{}, name string) error {
 	return m.RunWithValue(value, func(stmt *gorm.Statement) error {
 		constraint, chk, table := m.GuessConstraintAndTable(stmt, name)
 		if chk != nil {
-			return m.DB.Exec("ALTER TABLE ? DROP CHECK ?", clause.Table{Name: stmt.Table}, clause.Column{Name: chk.Name}).Error
-		}
-		if constraint != nil {
+			name = chk.Name
+		} else if constraint != nil {
 			name = constraint.Name
 		}
 
 		return m.DB.Exec(
-			"ALTER TABLE ? DROP FOREIGN KEY ?", clause.Table{Name: table}, clause.Column{Name: name},
+			"ALTER TABLE ? DROP CONSTRAINT ?", clause.Table{Name: table}, clause.Column{Name: name},
 		).Error
 	})
 }
@@ -182,7 +338,14 @@ func (m Migrator) DropConstraint(value interface{}, name string) error {
 func (m Migrator) ColumnTypes(value interface{}) ([]gorm.ColumnType, error) {
 	columnTypes := make([]gorm.ColumnType, 0)
 	err := m.RunWithValue(value, func(stmt *gorm.Statement) error {
-		currentDatabase := m.DB.Migrator().CurrentDatabase()
+		schemaName, tableName := m.schemaAndTable(m.tableName(stmt))
+		if m.CatalogSnapshot != nil {
+			if table, ok := m.CatalogSnapshot.lookup(schemaName, tableName); ok {
+				columnTypes = table.columnTypes()
+			}
+			return nil
+		}
+
 		columnTypeSQL := `SELECT
 		t.column_name,
 		t.is_nullable,
@@ -205,7 +368,7 @@ func (m Migrator) ColumnTypes(value interface{}) ([]gorm.ColumnType, error) {
 	ORDER BY
 		t.POSITION ASC`
 
-		columns, rowErr := m.DB.Raw(columnTypeSQL, currentDatabase, stmt.Table).Rows()
+		columns, rowErr := m.DB.Raw(columnTypeSQL, schemaName, tableName).Rows()
 		if rowErr != nil {
 			return rowErr
 		}
@@ -213,7 +376,9 @@ func (m Migrator) ColumnTypes(value interface{}) ([]gorm.ColumnType, error) {
 		defer columns.Close()
 
 		for columns.Next() {
-			var column = migrator.ColumnType{}
+			// the empty driver column type reports the lengths and decimal
+			// sizes cleared below as missing instead of panicking
+			var column = migrator.ColumnType{SQLColumnType: &sql.ColumnType{}}
 
 			var values = []interface{}{
 				&column.NameValue,
@@ -231,7 +396,22 @@ func (m Migrator) ColumnTypes(value interface{}) ([]gorm.ColumnType, error) {
 				return scanErr
 			}
 
-			column.DecimalSizeValue = column.LengthValue
+			column.ColumnTypeValue = sql.NullString{
+				String: columnTypeOf(column.DataTypeValue.String, column.LengthValue, column.ScaleValue),
+				Valid:  column.DataTypeValue.Valid,
+			}
+
+			// SYS.TABLE_COLUMNS reports a length for all types, which
+			// is the precision for decimals and the display size otherwise
+			if isDecimalDataType(column.DataTypeValue.String) {
+				column.DecimalSizeValue = column.LengthValue
+			}
+			if !hasLength(column.DataTypeValue.String) {
+				column.LengthValue = sql.NullInt64{}
+			}
+			if isTemporalDataType(column.DataTypeValue.String) {
+				column.ScaleValue = sql.NullInt64{}
+			}
 
 			columnTypes = append(columnTypes, column)
 		}
@@ -0,0 +1,87 @@
+package hdb
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"strings"
+	"unicode/utf8"
+
+	"gorm.io/gorm/schema"
+)
+
+// MaxIdentifierLength is the maximum length of HANA identifiers in characters
+const MaxIdentifierLength = 127
+
+// NamingStrategy produces HANA style UPPER_SNAKE names for tables, columns,
+// indexes and constraints, which match unquoted identifiers of existing schemas
+type NamingStrategy struct {
+	TablePrefix   string
+	SingularTable bool
+	NameReplacer  schema.Replacer
+}
+
+var _ schema.Namer = NamingStrategy{}
+
+func (ns NamingStrategy) base() schema.NamingStrategy {
+	return schema.NamingStrategy{
+		TablePrefix:   ns.TablePrefix,
+		SingularTable: ns.SingularTable,
+		NameReplacer:  ns.NameReplacer,
+	}
+}
+
+// TableName convert string to table name
+func (ns NamingStrategy) TableName(str string) string {
+	return truncateIdentifier(strings.ToUpper(ns.base().TableName(str)))
+}
+
+// SchemaName generate schema name from table name
+func (ns NamingStrategy) SchemaName(table string) string {
+	return ns.base().SchemaName(strings.ToLower(table))
+}
+
+// ColumnName convert string to column name
+func (ns NamingStrategy) ColumnName(table, column string) string {
+	return truncateIdentifier(strings.ToUpper(ns.base().ColumnName(table, column)))
+}
+
+// JoinTableName convert string to join table name
+func (ns NamingStrategy) JoinTableName(str string) string {
+	return truncateIdentifier(strings.ToUpper(ns.base().JoinTableName(str)))
+}
+
+// RelationshipFKName generate fk name for relation
+func (ns NamingStrategy) RelationshipFKName(rel schema.Relationship) string {
+	return formatIdentifier("FK", rel.Schema.Table, ns.ColumnName("", rel.Name))
+}
+
+// CheckerName generate checker name
+func (ns NamingStrategy) CheckerName(table, column string) string {
+	return formatIdentifier("CHK", table, column)
+}
+
+// IndexName generate index name
+func (ns NamingStrategy) IndexName(table, column string) string {
+	return formatIdentifier("IDX", table, ns.ColumnName("", column))
+}
+
+func formatIdentifier(prefix, table, name string) string {
+	return truncateIdentifier(strings.ToUpper(strings.ReplaceAll(
+		strings.Join([]string{prefix, table, name}, "_"), ".", "_",
+	)))
+}
+
+// truncateIdentifier shortens names longer than MaxIdentifierLength,
+// the tail is replaced by a hash of the full name to keep them unique
+func truncateIdentifier(name string) string {
+	if utf8.RuneCountInString(name) <= MaxIdentifierLength {
+		return name
+	}
+
+	h := sha1.New()
+	h.Write([]byte(name))
+	suffix := strings.ToUpper(hex.EncodeToString(h.Sum(nil))[:8])
+
+	runes := []rune(name)
+	return string(runes[:MaxIdentifierLength-len(suffix)-1]) + "_" + suffix
+}
@@ -0,0 +1,42 @@
+package hdb
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm/schema"
+)
+
+func TestNamingStrategy(t *testing.T) {
+	type SalesOrderItem struct {
+		ID          uint
+		OrderNumber string `gorm:"index"`
+		NetAmount   float64
+	}
+
+	ns := NamingStrategy{TablePrefix: "t_"}
+	s, err := schema.Parse(&SalesOrderItem{}, &sync.Map{}, ns)
+	assert.Nil(t, err)
+
+	assert.Equal(t, "T_SALES_ORDER_ITEMS", s.Table)
+	assert.Equal(t, "ID", s.LookUpField("ID").DBName)
+	assert.Equal(t, "ORDER_NUMBER", s.LookUpField("OrderNumber").DBName)
+	assert.Equal(t, "NET_AMOUNT", s.LookUpField("NetAmount").DBName)
+	assert.NotNil(t, s.LookIndex("IDX_T_SALES_ORDER_ITEMS_ORDER_NUMBER"))
+	assert.Equal(t, "SalesOrderItem", ns.SchemaName(s.Table))
+	assert.Equal(t, "CHK_T_SALES_ORDER_ITEMS_NET_AMOUNT", ns.CheckerName(s.Table, "NET_AMOUNT"))
+}
+
+func TestNamingStrategyTruncate(t *testing.T) {
+	ns := NamingStrategy{}
+	long := strings.Repeat("VeryLongName", 20)
+
+	name := ns.IndexName("TABLE", long)
+	assert.Equal(t, MaxIdentifierLength, utf8.RuneCountInString(name))
+	assert.Equal(t, name, ns.IndexName("TABLE", long))
+	assert.NotEqual(t, name, ns.IndexName("TABLE", long+"X"))
+	assert.Equal(t, strings.ToUpper(name), name)
+}
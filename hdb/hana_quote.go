@@ -0,0 +1,108 @@
+package hdb
+
+import (
+	"strings"
+
+	"gorm.io/gorm/clause"
+)
+
+// hanaReservedWords are the reserved words of HANA SQL, they
+// can only be used as identifier when delimited with double quotes
+var hanaReservedWords = map[string]struct{}{}
+
+func init() {
+	for _, word := range []string{
+		"ALL", "ALTER", "AS", "BEFORE", "BEGIN", "BOTH", "CASE", "CHAR",
+		"CONDITION", "CONNECT", "CROSS", "CUBE", "CURRENT_CONNECTION",
+		"CURRENT_DATE", "CURRENT_SCHEMA", "CURRENT_TIME", "CURRENT_TIMESTAMP",
+		"CURRENT_TRANSACTION_ISOLATION_LEVEL", "CURRENT_USER", "CURRENT_UTCDATE",
+		"CURRENT_UTCTIME", "CURRENT_UTCTIMESTAMP", "CURRVAL", "CURSOR", "DECLARE",
+		"DEFERRED", "DISTINCT", "ELSE", "ELSEIF", "END", "EXCEPT", "EXCEPTION",
+		"EXEC", "FALSE", "FOR", "FROM", "FULL", "GROUP", "HAVING", "IF", "IN",
+		"INNER", "INOUT", "INTERSECT", "INTO", "IS", "JOIN", "LATERAL", "LEADING",
+		"LEFT", "LIMIT", "LOOP", "MINUS", "NATURAL", "NCHAR", "NEXTVAL", "NULL",
+		"ON", "ORDER", "OUT", "PRIOR", "RETURN", "RETURNS", "REVERSE", "RIGHT",
+		"ROLLUP", "ROWID", "SELECT", "SESSION_USER", "SET", "SQL", "START",
+		"SYSUUID", "TABLESAMPLE", "TOP", "TRAILING", "TRUE", "UNION", "UNKNOWN",
+		"USING", "UTCTIMESTAMP", "VALUES", "WHEN", "WHERE", "WHILE", "WITH",
+	} {
+		hanaReservedWords[word] = struct{}{}
+	}
+}
+
+// IsReservedWord reports whether name is a HANA reserved word
+func IsReservedWord(name string) bool {
+	_, ok := hanaReservedWords[strings.ToUpper(name)]
+	return ok
+}
+
+// splitIdentifier splits a dotted identifier like `schema.table."column"`
+// into its parts, parts delimited with double quotes are unescaped.
+// Malformed quoted parts are taken literally.
+func splitIdentifier(str string) []string {
+	var parts []string
+
+	for {
+		part, rest, ok := cutQuotedPart(str)
+		if !ok {
+			if idx := strings.IndexByte(str, '.'); idx >= 0 {
+				part, rest = str[:idx], str[idx:]
+			} else {
+				part, rest = str, ""
+			}
+		}
+		parts = append(parts, part)
+
+		if rest == "" {
+			return parts
+		}
+		// skip the '.' delimiter
+		str = rest[1:]
+	}
+}
+
+// cutQuotedPart reads a double quoted identifier from the start of str,
+// the part has to be followed by the end of str or a '.' delimiter
+func cutQuotedPart(str string) (part, rest string, ok bool) {
+	if len(str) == 0 || str[0] != '"' {
+		return "", "", false
+	}
+
+	var builder strings.Builder
+	for i := 1; i < len(str); i++ {
+		if str[i] != '"' {
+			builder.WriteByte(str[i])
+			continue
+		}
+		if i+1 < len(str) && str[i+1] == '"' {
+			builder.WriteByte('"')
+			i++
+			continue
+		}
+		if rest = str[i+1:]; rest == "" || rest[0] == '.' {
+			return builder.String(), rest, true
+		}
+		return "", "", false
+	}
+
+	return "", "", false
+}
+
+// quoteIdentifier returns name delimited with double quotes
+func quoteIdentifier(name string) string {
+	var builder strings.Builder
+	writeQuotedIdentifier(&builder, []string{name})
+	return builder.String()
+}
+
+// writeQuotedIdentifier writes the identifier parts delimited by double quotes
+func writeQuotedIdentifier(writer clause.Writer, parts []string) {
+	for idx, part := range parts {
+		if idx > 0 {
+			writer.WriteByte('.')
+		}
+		writer.WriteByte('"')
+		writer.WriteString(strings.ReplaceAll(part, `"`, `""`))
+		writer.WriteByte('"')
+	}
+}
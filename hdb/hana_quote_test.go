@@ -0,0 +1,130 @@
+package hdb
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/quick"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// parseQuotedIdentifier strictly parses `"a"."b""c"` into its parts
+func parseQuotedIdentifier(str string) ([]string, error) {
+	var parts []string
+
+	for {
+		if len(str) == 0 || str[0] != '"' {
+			return nil, errors.New("identifier part must start with a double quote")
+		}
+
+		var part strings.Builder
+		closed := false
+		i := 1
+		for ; i < len(str); i++ {
+			if str[i] != '"' {
+				part.WriteByte(str[i])
+				continue
+			}
+			if i+1 < len(str) && str[i+1] == '"' {
+				part.WriteByte('"')
+				i++
+				continue
+			}
+			closed = true
+			break
+		}
+		if !closed {
+			return nil, errors.New("unterminated identifier part")
+		}
+		parts = append(parts, part.String())
+
+		str = str[i+1:]
+		if str == "" {
+			return parts, nil
+		}
+		if str[0] != '.' {
+			return nil, errors.New("identifier parts must be delimited by a dot")
+		}
+		str = str[1:]
+	}
+}
+
+func quote(str string) string {
+	var builder strings.Builder
+	Dialector{}.QuoteTo(&builder, str)
+	return builder.String()
+}
+
+func TestQuoteTo(t *testing.T) {
+	tests := []struct {
+		Input  string
+		Result string
+	}{
+		{"users", `"users"`},
+		{"schema.users.name", `"schema"."users"."name"`},
+		{`"users"`, `"users"`},
+		{`"my.schema"."users"`, `"my.schema"."users"`},
+		{`"schema".users`, `"schema"."users"`},
+		{`a"b`, `"a""b"`},
+		{`"a""b"`, `"a""b"`},
+		{`"unterminated`, `"""unterminated"`},
+		{`"a"b.c`, `"""a""b"."c"`},
+		{"``", "\"``\""},
+		{"", `""`},
+		{"a..b", `"a".""."b"`},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.Result, quote(test.Input), test.Input)
+	}
+}
+
+func TestQuoteToRoundTrip(t *testing.T) {
+	// quoting plain dotted names keeps their parts
+	plain := func(parts []string) bool {
+		if len(parts) == 0 {
+			return true
+		}
+		for idx, part := range parts {
+			parts[idx] = strings.ReplaceAll(part, ".", "")
+			if strings.HasPrefix(parts[idx], `"`) {
+				parts[idx] = "_" + parts[idx]
+			}
+		}
+		parsed, err := parseQuotedIdentifier(quote(strings.Join(parts, ".")))
+		return err == nil && assert.ObjectsAreEqual(parts, parsed)
+	}
+
+	// quoting already quoted names keeps their parts
+	quoted := func(parts []string) bool {
+		if len(parts) == 0 {
+			return true
+		}
+		escaped := make([]string, len(parts))
+		for idx, part := range parts {
+			escaped[idx] = `"` + strings.ReplaceAll(part, `"`, `""`) + `"`
+		}
+		parsed, err := parseQuotedIdentifier(quote(strings.Join(escaped, ".")))
+		return err == nil && assert.ObjectsAreEqual(parts, parsed)
+	}
+
+	// quoting is idempotent and always yields a well formed identifier
+	wellFormed := func(str string) bool {
+		once := quote(str)
+		parsed, err := parseQuotedIdentifier(once)
+		return err == nil && assert.ObjectsAreEqual(splitIdentifier(str), parsed) && quote(once) == once
+	}
+
+	for _, property := range []interface{}{plain, quoted, wellFormed} {
+		if err := quick.Check(property, &quick.Config{MaxCount: 2000}); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestIsReservedWord(t *testing.T) {
+	assert.True(t, IsReservedWord("select"))
+	assert.True(t, IsReservedWord("CURRENT_SCHEMA"))
+	assert.False(t, IsReservedWord("users"))
+}
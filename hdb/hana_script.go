@@ -0,0 +1,189 @@
+package hdb
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// MigrationScript is the DDL which the Migrator would execute, generated for
+// review instead of migrating at startup:
+//
+//	script, err := db.Migrator().(hdb.Migrator).AutoMigrateScript(&User{}, &Order{})
+//	fmt.Print(script.UpSQL())
+//
+// The catalog is read from the database or from Config.CatalogSnapshot.
+type MigrationScript struct {
+	// Up are the statements in execution order
+	Up []string
+	// Down are the statements which revert Up in execution order
+	Down []string
+	// Irreversible are the statements of Up without reverse statement, like dropped columns
+	Irreversible []string
+}
+
+// UpSQL returns the statements of Up terminated by semicolons
+func (s *MigrationScript) UpSQL() string {
+	return scriptSQL(nil, s.Up)
+}
+
+// DownSQL returns the statements of Down terminated by semicolons, preceded
+// by comments listing the irreversible statements
+func (s *MigrationScript) DownSQL() string {
+	return scriptSQL(s.Irreversible, s.Down)
+}
+
+func scriptSQL(comments, statements []string) string {
+	var builder strings.Builder
+	for _, c := range comments {
+		builder.WriteString("-- irreversible: ")
+		builder.WriteString(c)
+		builder.WriteByte('\n')
+	}
+	for _, stmt := range statements {
+		builder.WriteString(stmt)
+		builder.WriteString(";\n")
+	}
+	return builder.String()
+}
+
+// Script returns the statements executed by fc without executing them, e.g.
+//
+//	script, err := m.Script(func(m gorm.Migrator) error {
+//		return m.RenameColumn(&User{}, "name", "full_name")
+//	})
+//
+// Catalog lookups see the schema before the script, so fc should not depend on its own changes.
+func (m Migrator) Script(fc func(gorm.Migrator) error) (*MigrationScript, error) {
+	ctx := m.DB.Statement.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	pool := &scriptConnPool{ConnPool: m.DB.Statement.ConnPool, migrator: m}
+	tx := m.DB.WithContext(ctx)
+	tx.Statement.ConnPool = pool
+	if err := fc(tx.Migrator()); err != nil {
+		return nil, err
+	}
+
+	script := &MigrationScript{Up: pool.up, Irreversible: pool.irreversible}
+	for i := len(pool.down) - 1; i >= 0; i-- {
+		script.Down = append(script.Down, pool.down[i])
+	}
+	return script, nil
+}
+
+// AutoMigrateScript returns the statements AutoMigrate would execute for values
+func (m Migrator) AutoMigrateScript(values ...interface{}) (*MigrationScript, error) {
+	return m.Script(func(migrator gorm.Migrator) error {
+		return migrator.AutoMigrate(values...)
+	})
+}
+
+// scriptConnPool records the executed statements, queries read the catalog
+type scriptConnPool struct {
+	gorm.ConnPool
+	migrator     Migrator
+	up, down     []string
+	irreversible []string
+}
+
+func (p *scriptConnPool) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	stmt := p.migrator.Dialector.Explain(query, args...)
+	p.up = append(p.up, stmt)
+	if down, ok := p.reverse(stmt); ok {
+		p.down = append(p.down, down)
+	} else {
+		p.irreversible = append(p.irreversible, stmt)
+	}
+	return driver.RowsAffected(0), nil
+}
+
+const (
+	scriptIdentifier = `(?:"(?:[^"]|"")*"|[A-Za-z_][\w$#]*)`
+	scriptQualified  = scriptIdentifier + `(?:\.` + scriptIdentifier + `)*`
+)
+
+var (
+	createTableRegexp  = regexp.MustCompile(`^CREATE (?:\w+ )*TABLE (` + scriptQualified + `) `)
+	addColumnRegexp    = regexp.MustCompile(`^ALTER TABLE (` + scriptQualified + `) ADD \((` + scriptIdentifier + `) `)
+	alterColumnRegexp  = regexp.MustCompile(`^ALTER TABLE (` + scriptQualified + `) ALTER \((` + scriptIdentifier + `) `)
+	addConstraintRegex = regexp.MustCompile(`^ALTER TABLE (` + scriptQualified + `) ADD CONSTRAINT (` + scriptIdentifier + `) `)
+	createIndexRegexp  = regexp.MustCompile(`^CREATE (?:\w+ )*INDEX (` + scriptQualified + `) ON `)
+	renameColumnRegexp = regexp.MustCompile(`^RENAME COLUMN (` + scriptQualified + `)\.(` + scriptIdentifier + `) TO (` + scriptIdentifier + `)$`)
+	renameObjectRegexp = regexp.MustCompile(`^RENAME (TABLE|INDEX) (` + scriptQualified + `) TO (` + scriptIdentifier + `)$`)
+)
+
+// reverse returns the statement which reverts stmt
+func (p *scriptConnPool) reverse(stmt string) (string, bool) {
+	if match := createTableRegexp.FindStringSubmatch(stmt); match != nil {
+		return "DROP TABLE " + match[1], true
+	}
+	if match := addColumnRegexp.FindStringSubmatch(stmt); match != nil {
+		return "ALTER TABLE " + match[1] + " DROP (" + match[2] + ")", true
+	}
+	if match := alterColumnRegexp.FindStringSubmatch(stmt); match != nil {
+		if definition, ok := p.columnDefinition(match[1], match[2]); ok {
+			return "ALTER TABLE " + match[1] + " ALTER (" + match[2] + " " + definition + ")", true
+		}
+		return "", false
+	}
+	if match := addConstraintRegex.FindStringSubmatch(stmt); match != nil {
+		return "ALTER TABLE " + match[1] + " DROP CONSTRAINT " + match[2], true
+	}
+	if match := createIndexRegexp.FindStringSubmatch(stmt); match != nil {
+		return "DROP INDEX " + match[1], true
+	}
+	if match := renameColumnRegexp.FindStringSubmatch(stmt); match != nil {
+		return "RENAME COLUMN " + match[1] + "." + match[3] + " TO " + match[2], true
+	}
+	if match := renameObjectRegexp.FindStringSubmatch(stmt); match != nil {
+		// objects are renamed within their schema
+		parts := splitIdentifier(match[2])
+		newName := append(parts[:len(parts)-1:len(parts)-1], splitIdentifier(match[3])[0])
+		var builder strings.Builder
+		writeQuotedIdentifier(&builder, newName)
+		return "RENAME " + match[1] + " " + builder.String() + " TO " + quoteIdentifier(parts[len(parts)-1]), true
+	}
+	return "", false
+}
+
+// columnDefinition returns the current definition of a column from the catalog
+func (p *scriptConnPool) columnDefinition(table, column string) (string, bool) {
+	name := splitIdentifier(column)[0]
+	columnTypes, err := p.migrator.ColumnTypes(strings.Join(splitIdentifier(table), "."))
+	if err != nil {
+		return "", false
+	}
+
+	for _, columnType := range columnTypes {
+		if columnType.Name() != name {
+			continue
+		}
+		definition, ok := columnType.ColumnType()
+		if !ok {
+			return "", false
+		}
+		if nullable, ok := columnType.Nullable(); ok && !nullable {
+			definition += " NOT NULL"
+		}
+		if value, ok := columnType.DefaultValue(); ok {
+			if _, err := strconv.ParseFloat(value, 64); err == nil || strings.EqualFold(columnType.DatabaseTypeName(), "BOOLEAN") {
+				definition += " DEFAULT " + value
+			} else {
+				definition += " DEFAULT " + p.migrator.Dialector.Explain("?", value)
+			}
+		}
+		if comment, ok := columnType.Comment(); ok {
+			definition += " COMMENT " + p.migrator.Dialector.Explain("?", comment)
+		}
+		return definition, true
+	}
+	return "", false
+}
@@ -0,0 +1,121 @@
+package hdb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"gorm.io/driver/hana/hdb/hanatest"
+)
+
+type scriptCustomer struct {
+	ID     uint   `gorm:"primaryKey;autoIncrement:false"`
+	Name   string `gorm:"size:100;not null"`
+	Email  string `gorm:"size:200;index"`
+	Orders []scriptOrder
+}
+
+type scriptOrder struct {
+	ID               uint `gorm:"primaryKey;autoIncrement:false"`
+	ScriptCustomerID uint
+}
+
+var scriptSnapshot = &Snapshot{
+	Schema: "SALES",
+	Tables: []CatalogTable{{
+		Name: "script_customers",
+		Columns: []CatalogColumn{
+			{Name: "id", DataType: "INTEGER"},
+			{Name: "name", DataType: "NVARCHAR", Length: 50, DefaultValue: "unknown", Comment: "display name"},
+			{Name: "legacy", DataType: "INTEGER", Nullable: true},
+		},
+		PrimaryKey: []string{"id"},
+	}},
+}
+
+func newScriptDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(New(Config{CatalogSnapshot: scriptSnapshot}), &gorm.Config{Logger: logger.Discard})
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	return db
+}
+
+func TestAutoMigrateScript(t *testing.T) {
+	db := newScriptDB(t)
+
+	script, err := db.Migrator().(Migrator).AutoMigrateScript(&scriptCustomer{}, &scriptOrder{})
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, []string{
+		`ALTER TABLE "script_customers" ALTER ("name" nvarchar(100) NOT NULL)`,
+		`ALTER TABLE "script_customers" ADD ("email" nvarchar(200))`,
+		`CREATE INDEX "idx_script_customers_email" ON "script_customers"("email")`,
+		`CREATE TABLE "script_orders" ("id" bigint,"script_customer_id" bigint,PRIMARY KEY ("id"),` +
+			`CONSTRAINT "fk_script_customers_orders" FOREIGN KEY ("script_customer_id") REFERENCES "script_customers"("id"))`,
+	}, script.Up)
+	assert.Equal(t, []string{
+		`DROP TABLE "script_orders"`,
+		`DROP INDEX "idx_script_customers_email"`,
+		`ALTER TABLE "script_customers" DROP ("email")`,
+		`ALTER TABLE "script_customers" ALTER ("name" NVARCHAR(50) NOT NULL DEFAULT 'unknown' COMMENT 'display name')`,
+	}, script.Down)
+	assert.Empty(t, script.Irreversible)
+}
+
+func TestScript(t *testing.T) {
+	db := newScriptDB(t)
+
+	script, err := db.Migrator().(Migrator).Script(func(m gorm.Migrator) error {
+		if err := m.RenameColumn(&scriptCustomer{}, "legacy", "legacy_id"); err != nil {
+			return err
+		}
+		if err := m.RenameTable("SALES.script_customers", "customers"); err != nil {
+			return err
+		}
+		return m.DropColumn("customers", "legacy_id")
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, []string{
+		`RENAME COLUMN "script_customers"."legacy" TO "legacy_id"`,
+		`RENAME TABLE "SALES"."script_customers" TO "customers"`,
+		`ALTER TABLE "customers" DROP ("legacy_id")`,
+	}, script.Up)
+	assert.Equal(t, []string{
+		`RENAME TABLE "SALES"."customers" TO "script_customers"`,
+		`RENAME COLUMN "script_customers"."legacy_id" TO "legacy"`,
+	}, script.Down)
+	assert.Equal(t, []string{`ALTER TABLE "customers" DROP ("legacy_id")`}, script.Irreversible)
+	assert.Equal(t, "-- irreversible: ALTER TABLE \"customers\" DROP (\"legacy_id\")\n"+
+		"RENAME TABLE \"SALES\".\"customers\" TO \"script_customers\";\n"+
+		"RENAME COLUMN \"script_customers\".\"legacy_id\" TO \"legacy\";\n", script.DownSQL())
+}
+
+func TestAutoMigrateScriptLive(t *testing.T) {
+	catalog := hanatest.NewCatalog(hanatest.Table{
+		Name: "script_customers",
+		Columns: []hanatest.TableColumn{
+			{Name: "id", DataType: "BIGINT", Length: 19, PrimaryKey: true},
+			{Name: "name", DataType: "NVARCHAR", Length: 100},
+		},
+	})
+	db, err := gorm.Open(New(Config{Connector: catalog}), &gorm.Config{Logger: logger.Discard})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	script, err := db.Migrator().(Migrator).AutoMigrateScript(&scriptCustomer{})
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, []string{
+		`ALTER TABLE "script_customers" ADD ("email" nvarchar(200))`,
+		`CREATE INDEX "idx_script_customers_email" ON "script_customers"("email")`,
+	}, script.Up)
+	assert.Empty(t, catalog.DDL())
+}
@@ -0,0 +1,372 @@
+package hdb
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// Spatial columns are created with the spatial reference system of the `srid` tag,
+// which is also written for created and updated values without SRID, so that
+// scanned values can be saved back:
+//
+//	type Store struct {
+//		Location hdb.Point    `gorm:"srid:4326"`
+//		Pickup   *hdb.Point   `gorm:"srid:4326"`
+//		Area     hdb.Geometry `gorm:"srid:4326"`
+//	}
+//
+// HANA returns spatial values as WKB without SRID, which is decoded by Scan.
+// Nullable point columns need *Point fields, as the zero Point is POINT (0 0).
+
+const (
+	wkbPoint uint32 = 1
+	// extended WKB flags
+	ewkbZ    uint32 = 0x80000000
+	ewkbM    uint32 = 0x40000000
+	ewkbSRID uint32 = 0x20000000
+)
+
+// ErrInvalidWKB is returned for spatial values which are no valid WKB
+var ErrInvalidWKB = errors.New("invalid WKB")
+
+type geometryExpression interface {
+	geometryExpr() clause.Expr
+}
+
+// Point is a ST_POINT model field
+type Point struct {
+	X, Y float64
+	SRID int
+}
+
+// GormDataType gorm common data type
+func (Point) GormDataType() string {
+	return "st_point"
+}
+
+// GormDBDataType gorm db data type
+func (Point) GormDBDataType(db *gorm.DB, field *schema.Field) string {
+	return spatialDBDataType("st_point", field)
+}
+
+// GormValue writes the point as WKB
+func (p Point) GormValue(ctx context.Context, db *gorm.DB) clause.Expr {
+	return p.geometryExpr()
+}
+
+// CreateClauses sets the SRID of the `srid` tag for created points without SRID
+func (Point) CreateClauses(f *schema.Field) []clause.Interface {
+	return []clause.Interface{spatialSRIDClause{field: f}}
+}
+
+// UpdateClauses sets the SRID of the `srid` tag for updated points without SRID
+func (Point) UpdateClauses(f *schema.Field) []clause.Interface {
+	return []clause.Interface{spatialSRIDClause{field: f}}
+}
+
+func (p Point) withSRID(srid int) interface{} {
+	if p.SRID == 0 {
+		p.SRID = srid
+	}
+	return p
+}
+
+func (p Point) geometryExpr() clause.Expr {
+	return clause.Expr{SQL: "ST_GeomFromWKB(?, ?)", Vars: []interface{}{bytesValue(p.WKB()), p.SRID}}
+}
+
+// WKB returns the little endian well-known binary representation
+func (p Point) WKB() []byte {
+	b := make([]byte, 21)
+	b[0] = 1
+	binary.LittleEndian.PutUint32(b[1:], wkbPoint)
+	binary.LittleEndian.PutUint64(b[5:], math.Float64bits(p.X))
+	binary.LittleEndian.PutUint64(b[13:], math.Float64bits(p.Y))
+	return b
+}
+
+// WKT returns the well-known text representation
+func (p Point) WKT() string {
+	return "POINT (" + strconv.FormatFloat(p.X, 'f', -1, 64) + " " + strconv.FormatFloat(p.Y, 'f', -1, 64) + ")"
+}
+
+// Scan implements the database/sql/Scanner interface
+func (p *Point) Scan(src interface{}) error {
+	b, err := scanWKB(src)
+	if err != nil || b == nil {
+		*p = Point{}
+		return err
+	}
+
+	point, err := decodeWKBPoint(b)
+	if err != nil {
+		return err
+	}
+	*p = point
+	return nil
+}
+
+// Geometry is a ST_GEOMETRY model field. A geometry is written from WKB,
+// or from WKT if WKB is nil, read geometries hold WKB only.
+type Geometry struct {
+	WKB  []byte
+	WKT  string
+	SRID int
+}
+
+// GormDataType gorm common data type
+func (Geometry) GormDataType() string {
+	return "st_geometry"
+}
+
+// GormDBDataType gorm db data type
+func (Geometry) GormDBDataType(db *gorm.DB, field *schema.Field) string {
+	return spatialDBDataType("st_geometry", field)
+}
+
+// GormValue writes the geometry as WKB or WKT, an empty geometry as NULL
+func (g Geometry) GormValue(ctx context.Context, db *gorm.DB) clause.Expr {
+	return g.geometryExpr()
+}
+
+func (g Geometry) geometryExpr() clause.Expr {
+	switch {
+	case g.WKB != nil:
+		return clause.Expr{SQL: "ST_GeomFromWKB(?, ?)", Vars: []interface{}{bytesValue(g.WKB), g.SRID}}
+	case g.WKT != "":
+		return clause.Expr{SQL: "ST_GeomFromWKT(?, ?)", Vars: []interface{}{g.WKT, g.SRID}}
+	}
+	return clause.Expr{SQL: "NULL"}
+}
+
+// CreateClauses sets the SRID of the `srid` tag for created geometries without SRID
+func (Geometry) CreateClauses(f *schema.Field) []clause.Interface {
+	return []clause.Interface{spatialSRIDClause{field: f}}
+}
+
+// UpdateClauses sets the SRID of the `srid` tag for updated geometries without SRID
+func (Geometry) UpdateClauses(f *schema.Field) []clause.Interface {
+	return []clause.Interface{spatialSRIDClause{field: f}}
+}
+
+func (g Geometry) withSRID(srid int) interface{} {
+	if g.SRID == 0 {
+		g.SRID = srid
+	}
+	return g
+}
+
+// Point decodes the geometry as point
+func (g Geometry) Point() (Point, error) {
+	return decodeWKBPoint(g.WKB)
+}
+
+// Scan implements the database/sql/Scanner interface
+func (g *Geometry) Scan(src interface{}) error {
+	b, err := scanWKB(src)
+	if err != nil {
+		return err
+	}
+
+	*g = Geometry{WKB: b}
+	if b != nil {
+		if _, srid, _, err := decodeWKBHeader(b); err == nil {
+			g.SRID = srid
+		}
+	}
+	return nil
+}
+
+// STWithin is true if the geometry of column is within g
+//
+//	db.Where(hdb.STWithin("location", area))
+func STWithin(column string, g geometryExpression) clause.Expression {
+	return spatialPredicate("ST_Within", column, g)
+}
+
+// STIntersects is true if the geometry of column intersects g
+func STIntersects(column string, g geometryExpression) clause.Expression {
+	return spatialPredicate("ST_Intersects", column, g)
+}
+
+// STDistance is the distance between the geometry of column and g,
+// in the unit of the spatial reference system if unit is empty
+//
+//	db.Where("? < ?", hdb.STDistance("location", hdb.Point{X: 8.64, Y: 49.29, SRID: 4326}, "meter"), 500)
+func STDistance(column string, g geometryExpression, unit string) clause.Expression {
+	if unit == "" {
+		return clause.Expr{SQL: "?.ST_Distance(?)", Vars: []interface{}{clause.Column{Name: column}, g.geometryExpr()}}
+	}
+	return clause.Expr{SQL: "?.ST_Distance(?, ?)", Vars: []interface{}{clause.Column{Name: column}, g.geometryExpr(), unit}}
+}
+
+// bytesValue binds bytes as single value, clause.Expr expands
+// byte slices following a parenthesis into value lists
+type bytesValue []byte
+
+// Value implements the database/sql/Valuer interface
+func (v bytesValue) Value() (driver.Value, error) {
+	return []byte(v), nil
+}
+
+func spatialPredicate(method, column string, g geometryExpression) clause.Expression {
+	return clause.Expr{SQL: "?." + method + "(?) = 1", Vars: []interface{}{clause.Column{Name: column}, g.geometryExpr()}}
+}
+
+// sridSetter is a spatial value which takes the SRID of its column
+type sridSetter interface {
+	withSRID(srid int) interface{}
+}
+
+// spatialSRIDClause sets the SRID of the `srid` tag of field for the created
+// or updated values without SRID, like the scanned values
+type spatialSRIDClause struct {
+	field *schema.Field
+}
+
+func (c spatialSRIDClause) Name() string {
+	return ""
+}
+
+func (c spatialSRIDClause) Build(clause.Builder) {
+}
+
+func (c spatialSRIDClause) MergeClause(*clause.Clause) {
+}
+
+func (c spatialSRIDClause) ModifyStatement(stmt *gorm.Statement) {
+	srid, err := strconv.Atoi(c.field.TagSettings["SRID"])
+	if err != nil || srid == 0 {
+		return
+	}
+
+	if values, ok := stmt.Dest.(map[string]interface{}); ok {
+		for key, value := range values {
+			if key != c.field.Name && key != c.field.DBName {
+				continue
+			}
+			if p, ok := value.(*Point); ok && p != nil {
+				value = *p
+			}
+			if setter, ok := value.(sridSetter); ok {
+				values[key] = setter.withSRID(srid)
+			}
+		}
+	}
+
+	c.setSRID(stmt, stmt.ReflectValue, srid)
+	if dest := reflect.ValueOf(stmt.Dest); dest.Kind() == reflect.Ptr && dest.Elem() != stmt.ReflectValue {
+		c.setSRID(stmt, dest.Elem(), srid)
+	}
+}
+
+// setSRID sets the SRID of the field of the records of rv
+func (c spatialSRIDClause) setSRID(stmt *gorm.Statement, rv reflect.Value, srid int) {
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			c.setSRID(stmt, reflect.Indirect(rv.Index(i)), srid)
+		}
+	case reflect.Struct:
+		if !rv.CanAddr() || rv.Type() != c.field.Schema.ModelType {
+			return
+		}
+		fieldValue := c.field.ReflectValueOf(stmt.Context, rv)
+		if fieldValue.Kind() == reflect.Ptr {
+			if fieldValue.IsNil() {
+				return
+			}
+			fieldValue = fieldValue.Elem()
+		}
+		if setter, ok := fieldValue.Interface().(sridSetter); ok {
+			fieldValue.Set(reflect.ValueOf(setter.withSRID(srid)))
+		}
+	}
+}
+
+func spatialDBDataType(dataType string, field *schema.Field) string {
+	if t, ok := field.TagSettings["TYPE"]; ok {
+		return t
+	}
+	if srid, ok := field.TagSettings["SRID"]; ok {
+		return fmt.Sprintf("%s(%s)", dataType, srid)
+	}
+	return dataType
+}
+
+// scanWKB accepts WKB as bytes or as hex string, as returned by go-hdb
+func scanWKB(src interface{}) ([]byte, error) {
+	switch src := src.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		return append([]byte(nil), src...), nil
+	case string:
+		b, err := hex.DecodeString(src)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidWKB, err)
+		}
+		return b, nil
+	}
+	return nil, fmt.Errorf("spatial: invalid scan type %T", src)
+}
+
+// decodeWKBHeader decodes byte order, geometry type and the SRID of extended WKB
+func decodeWKBHeader(b []byte) (geometryType uint32, srid int, order binary.ByteOrder, err error) {
+	if len(b) < 5 {
+		return 0, 0, nil, ErrInvalidWKB
+	}
+
+	switch b[0] {
+	case 0:
+		order = binary.BigEndian
+	case 1:
+		order = binary.LittleEndian
+	default:
+		return 0, 0, nil, ErrInvalidWKB
+	}
+
+	geometryType = order.Uint32(b[1:])
+	if geometryType&ewkbSRID != 0 {
+		if len(b) < 9 {
+			return 0, 0, nil, ErrInvalidWKB
+		}
+		srid = int(order.Uint32(b[5:]))
+	}
+	return geometryType, srid, order, nil
+}
+
+func decodeWKBPoint(b []byte) (Point, error) {
+	geometryType, srid, order, err := decodeWKBHeader(b)
+	if err != nil {
+		return Point{}, err
+	}
+
+	offset := 5
+	if geometryType&ewkbSRID != 0 {
+		offset += 4
+	}
+	// ISO WKB encodes Z and M as 1000, 2000 and 3000 offsets
+	if (geometryType&^(ewkbZ|ewkbM|ewkbSRID))%1000 != wkbPoint {
+		return Point{}, fmt.Errorf("%w: geometry type %d is no point", ErrInvalidWKB, geometryType)
+	}
+
+	rd := bytes.NewReader(b[offset:])
+	var coords [2]float64
+	if err := binary.Read(rd, order, &coords); err != nil {
+		return Point{}, fmt.Errorf("%w: %v", ErrInvalidWKB, err)
+	}
+	return Point{X: coords[0], Y: coords[1], SRID: srid}, nil
+}
@@ -0,0 +1,85 @@
+package hdb
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpatial(t *testing.T) {
+	type Store struct {
+		ID       uint
+		Location Point    `gorm:"srid:4326"`
+		Area     Geometry `gorm:"srid:4326"`
+	}
+
+	db := newDryRunDB(t)
+	stmt := db.Model(&Store{}).Statement
+	assert.Nil(t, stmt.Parse(&Store{}))
+	m := db.Migrator().(Migrator)
+	assert.Equal(t, "st_point(4326)", m.FullDataTypeOf(stmt.Schema.LookUpField("Location")).SQL)
+	assert.Equal(t, "st_geometry(4326)", m.FullDataTypeOf(stmt.Schema.LookUpField("Area")).SQL)
+
+	location := Point{X: 8.64, Y: 49.29, SRID: 4326}
+	area := Geometry{WKT: "POLYGON ((8 49, 9 49, 9 50, 8 50, 8 49))", SRID: 4326}
+
+	create := db.Create(&Store{Location: location, Area: area}).Statement
+	assert.Equal(t, `INSERT INTO "stores" ("location","area") VALUES (ST_GeomFromWKB(?, ?),ST_GeomFromWKT(?, ?))`, create.SQL.String())
+
+	query := db.Where(STWithin("location", area)).Where("? < ?", STDistance("location", location, "meter"), 500).Find(&[]Store{}).Statement
+	assert.Equal(t, `SELECT * FROM "stores" WHERE "location".ST_Within(ST_GeomFromWKT(?, ?)) = 1 AND "location".ST_Distance(ST_GeomFromWKB(?, ?), ?) < ?`, query.SQL.String())
+
+	query = db.Where(STIntersects("area", location)).Find(&[]Store{}).Statement
+	assert.Equal(t, `SELECT * FROM "stores" WHERE "area".ST_Intersects(ST_GeomFromWKB(?, ?)) = 1`, query.SQL.String())
+
+	// HANA returns spatial values as hex encoded WKB
+	var scanned Point
+	assert.Nil(t, scanned.Scan(hex.EncodeToString(location.WKB())))
+	assert.Equal(t, Point{X: 8.64, Y: 49.29}, scanned)
+
+	// big endian extended WKB with SRID
+	ewkb, _ := hex.DecodeString("0020000001000010e640200000000000004049000000000000")
+	var geometry Geometry
+	assert.Nil(t, geometry.Scan(ewkb))
+	assert.Equal(t, 4326, geometry.SRID)
+	point, err := geometry.Point()
+	assert.Nil(t, err)
+	assert.Equal(t, Point{X: 8, Y: 50, SRID: 4326}, point)
+	assert.Equal(t, "POINT (8 50)", point.WKT())
+
+	assert.ErrorIs(t, scanned.Scan([]byte{1, 2}), ErrInvalidWKB)
+}
+
+func TestSpatialSaveScanned(t *testing.T) {
+	type Store struct {
+		ID       uint     `gorm:"primaryKey;autoIncrement:false"`
+		Location Point    `gorm:"srid:4326"`
+		Pickup   *Point   `gorm:"srid:4326"`
+		Area     Geometry `gorm:"srid:4326"`
+	}
+
+	db := newDryRunDB(t)
+
+	// HANA returns WKB without SRID, POINT (0 0) is a valid point
+	var location Point
+	assert.Nil(t, location.Scan(hex.EncodeToString(Point{}.WKB())))
+	var area Geometry
+	assert.Nil(t, area.Scan(Point{X: 1, Y: 2}.WKB()))
+	store := Store{ID: 1, Location: location, Area: area}
+
+	save := db.Save(&store).Statement
+	assert.Equal(t, `UPDATE "stores" SET "location"=ST_GeomFromWKB(?, ?),"pickup"=?,"area"=ST_GeomFromWKB(?, ?) WHERE "id" = ?`, save.SQL.String())
+	assert.Equal(t, []interface{}{bytesValue(Point{}.WKB()), 4326, nil, bytesValue(area.WKB), 4326, uint(1)}, save.Vars)
+	assert.Equal(t, Point{SRID: 4326}, store.Location)
+
+	create := db.Create(&[]Store{{ID: 2, Pickup: &location}}).Statement
+	assert.Equal(t, `INSERT INTO "stores" ("id","location","pickup","area") VALUES (?,ST_GeomFromWKB(?, ?),ST_GeomFromWKB(?, ?),NULL)`, create.SQL.String())
+	assert.Equal(t, []interface{}{uint(2), bytesValue(Point{}.WKB()), 4326, bytesValue(Point{}.WKB()), 4326}, create.Vars)
+
+	// explicit SRIDs are kept
+	update := db.Model(&Store{ID: 1}).Update("location", Point{X: 1, Y: 2, SRID: 3857}).Statement
+	assert.Equal(t, []interface{}{bytesValue(Point{X: 1, Y: 2}.WKB()), 3857, uint(1)}, update.Vars)
+	update = db.Model(&Store{ID: 1}).Updates(map[string]interface{}{"pickup": &Point{X: 1, Y: 2}}).Statement
+	assert.Equal(t, []interface{}{bytesValue(Point{X: 1, Y: 2}.WKB()), 4326, uint(1)}, update.Vars)
+}
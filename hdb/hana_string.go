@@ -0,0 +1,145 @@
+package hdb
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm/schema"
+)
+
+const (
+	// DataTypeVarchar ASCII string
+	DataTypeVarchar schema.DataType = "varchar"
+	// DataTypeAlphanum alphanumeric string, numeric values are sorted by their number
+	DataTypeAlphanum schema.DataType = "alphanum"
+	// DataTypeShortText unicode string with full text search
+	DataTypeShortText schema.DataType = "shorttext"
+	// DataTypeText unicode large object with full text search
+	DataTypeText schema.DataType = "text"
+	// DataTypeBinText binary large object with full text search
+	DataTypeBinText schema.DataType = "bintext"
+)
+
+// maxAlphanumLength is the maximum length of ALPHANUM columns
+const maxAlphanumLength = 127
+
+// The column type of string fields is chosen by the `stringtype` tag, the length by the size tag:
+//
+//	type Material struct {
+//		Number      hdb.Alphanum `gorm:"stringtype:alphanum;size:18"`
+//		Code        string       `gorm:"stringtype:varchar;size:10"`
+//		Description string       `gorm:"stringtype:shorttext;size:200"`
+//		Notes       hdb.NLob     `gorm:"type:text"`
+//	}
+//
+// TEXT and BINTEXT columns are read as lobs, so they are declared as NLob or Lob with the type tag.
+
+// stringDataType returns the column type of the `stringtype` tag, ok is false without tag
+func stringDataType(field *schema.Field) (dataType string, ok bool) {
+	stringType, ok := field.TagSettings["STRINGTYPE"]
+	if !ok {
+		return "", false
+	}
+
+	size := field.Size
+	switch dataType := schema.DataType(strings.ToLower(stringType)); dataType {
+	case DataTypeAlphanum:
+		if size == 0 {
+			size = maxAlphanumLength
+		}
+		return fmt.Sprintf("%s(%d)", dataType, size), true
+	case DataTypeVarchar, DataTypeShortText:
+		if size == 0 {
+			size = 255
+		}
+		if size > maxVarLength {
+			if dataType == DataTypeVarchar {
+				return "clob", true
+			}
+			return string(DataTypeText), true
+		}
+		return fmt.Sprintf("%s(%d)", dataType, size), true
+	case DataTypeText, DataTypeBinText:
+		return string(dataType), true
+	}
+	// nvarchar and others like char(10) are used as given
+	if size > 0 && !strings.Contains(stringType, "(") {
+		return fmt.Sprintf("%s(%d)", stringType, size), true
+	}
+	return stringType, true
+}
+
+// Alphanum is an ALPHANUM model field. HANA pads numeric values with leading zeros
+// to the column length, Scan removes them so that values are read as written.
+type Alphanum string
+
+// IsNumeric reports whether a consists of digits only, which HANA sorts by their number
+func (a Alphanum) IsNumeric() bool {
+	if a == "" {
+		return false
+	}
+	for i := 0; i < len(a); i++ {
+		if a[i] < '0' || a[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Normalize removes the leading zeros of numeric values
+func (a Alphanum) Normalize() Alphanum {
+	if !a.IsNumeric() {
+		return a
+	}
+	if trimmed := strings.TrimLeft(string(a), "0"); trimmed != "" {
+		return Alphanum(trimmed)
+	}
+	return "0"
+}
+
+// Compare compares a and b in the ALPHANUM order: numeric values by their
+// number and before alphabetic values, which are compared as strings
+func (a Alphanum) Compare(b Alphanum) int {
+	aNumeric, bNumeric := a.IsNumeric(), b.IsNumeric()
+	switch {
+	case aNumeric && bNumeric:
+		a, b = a.Normalize(), b.Normalize()
+		if len(a) != len(b) {
+			if len(a) < len(b) {
+				return -1
+			}
+			return 1
+		}
+	case aNumeric:
+		return -1
+	case bNumeric:
+		return 1
+	}
+	return strings.Compare(string(a), string(b))
+}
+
+// GormDataType gorm common data type
+func (Alphanum) GormDataType() string {
+	return string(schema.String)
+}
+
+// Scan implements the database/sql/Scanner interface
+func (a *Alphanum) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case nil:
+		*a = ""
+	case []byte:
+		*a = Alphanum(src).Normalize()
+	case string:
+		*a = Alphanum(src).Normalize()
+	default:
+		return fmt.Errorf("alphanum: invalid scan type %T", src)
+	}
+	return nil
+}
+
+// Value implements the database/sql/Valuer interface
+func (a Alphanum) Value() (driver.Value, error) {
+	return string(a), nil
+}
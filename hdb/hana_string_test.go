@@ -0,0 +1,76 @@
+package hdb
+
+import (
+	"database/sql"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStringDataType(t *testing.T) {
+	type Material struct {
+		ID          uint
+		Number      Alphanum `gorm:"stringtype:alphanum;size:18"`
+		Group       string   `gorm:"stringtype:ALPHANUM"`
+		Code        string   `gorm:"stringtype:varchar;size:10"`
+		Legacy      string   `gorm:"stringtype:varchar;size:8000"`
+		Description string   `gorm:"stringtype:shorttext;size:200"`
+		Summary     string   `gorm:"stringtype:shorttext"`
+		Flag        string   `gorm:"stringtype:char;size:1"`
+		Name        string   `gorm:"size:40"`
+		Notes       NLob     `gorm:"type:text"`
+	}
+
+	db := newDryRunDB(t)
+	stmt := db.Model(&Material{}).Statement
+	assert.Nil(t, stmt.Parse(&Material{}))
+	m := db.Migrator().(Migrator)
+	for name, dataType := range map[string]string{
+		"Number":      "alphanum(18)",
+		"Group":       "alphanum(127)",
+		"Code":        "varchar(10)",
+		"Legacy":      "clob",
+		"Description": "shorttext(200)",
+		"Summary":     "shorttext(255)",
+		"Flag":        "char(1)",
+		"Name":        "nvarchar(40)",
+		"Notes":       "text",
+	} {
+		assert.Equal(t, dataType, m.FullDataTypeOf(stmt.Schema.LookUpField(name)).SQL, name)
+	}
+}
+
+func TestColumnTypeOf(t *testing.T) {
+	length := sql.NullInt64{Int64: 10, Valid: true}
+	scale := sql.NullInt64{Int64: 2, Valid: true}
+
+	assert.Equal(t, "ALPHANUM(10)", columnTypeOf("ALPHANUM", length, sql.NullInt64{}))
+	assert.Equal(t, "SHORTTEXT(10)", columnTypeOf("SHORTTEXT", length, sql.NullInt64{}))
+	assert.Equal(t, "DECIMAL(10, 2)", columnTypeOf("DECIMAL", length, scale))
+	assert.Equal(t, "DECIMAL", columnTypeOf("DECIMAL", length, sql.NullInt64{}))
+	assert.Equal(t, "TEXT", columnTypeOf("TEXT", sql.NullInt64{}, sql.NullInt64{}))
+	assert.Equal(t, "INTEGER", columnTypeOf("INTEGER", length, sql.NullInt64{}))
+}
+
+func TestAlphanum(t *testing.T) {
+	var a Alphanum
+	assert.Nil(t, a.Scan([]byte("000000000000000042")))
+	assert.Equal(t, Alphanum("42"), a)
+	assert.Nil(t, a.Scan("0000"))
+	assert.Equal(t, Alphanum("0"), a)
+	assert.Nil(t, a.Scan("00A1"))
+	assert.Equal(t, Alphanum("00A1"), a)
+	assert.Nil(t, a.Scan(nil))
+	assert.Equal(t, Alphanum(""), a)
+	assert.NotNil(t, a.Scan(42))
+
+	value, err := Alphanum("0042").Value()
+	assert.Nil(t, err)
+	assert.Equal(t, "0042", value)
+
+	values := []Alphanum{"B1", "100", "A", "9", "0010", "A10"}
+	sort.Slice(values, func(i, j int) bool { return values[i].Compare(values[j]) < 0 })
+	assert.Equal(t, []Alphanum{"9", "0010", "100", "A", "A10", "B1"}, values)
+	assert.Equal(t, 0, Alphanum("010").Compare("10"))
+}
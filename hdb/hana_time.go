@@ -0,0 +1,172 @@
+package hdb
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+const (
+	// DataTypeDate calendar date
+	DataTypeDate schema.DataType = "date"
+	// DataTypeTime time of day with second precision
+	DataTypeTime schema.DataType = "time"
+	// DataTypeSecondDate date and time with second precision
+	DataTypeSecondDate schema.DataType = "seconddate"
+	// DataTypeTimestamp date and time with 100 nanosecond precision
+	DataTypeTimestamp schema.DataType = "timestamp"
+)
+
+const (
+	dateLayout       = "2006-01-02"
+	timeLayout       = "15:04:05"
+	secondDateLayout = time.RFC3339
+)
+
+// HANA date and time columns store no time zone, values are written and read as UTC
+// by the driver. Date and Time keep the calendar date and the wall clock of their
+// own location, SecondDate keeps the instant.
+
+// Date is a DATE model field, the time of day is dropped
+type Date time.Time
+
+// GormDataType gorm common data type
+func (Date) GormDataType() string {
+	return string(DataTypeDate)
+}
+
+// Scan implements the database/sql/Scanner interface
+func (d *Date) Scan(src interface{}) error {
+	t, err := scanTime(src)
+	*d = Date(t)
+	return err
+}
+
+// Value implements the database/sql/Valuer interface
+func (d Date) Value() (driver.Value, error) {
+	y, m, day := time.Time(d).Date()
+	return time.Date(y, m, day, 0, 0, 0, 0, time.UTC), nil
+}
+
+func (d Date) String() string {
+	return time.Time(d).Format(dateLayout)
+}
+
+// MarshalJSON encodes the date as "2006-01-02"
+func (d Date) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(d.String())), nil
+}
+
+// UnmarshalJSON decodes the date from "2006-01-02"
+func (d *Date) UnmarshalJSON(b []byte) error {
+	t, err := unmarshalTime(b, dateLayout)
+	*d = Date(t)
+	return err
+}
+
+// Time is a TIME model field, the date and fractional seconds are dropped
+type Time time.Time
+
+// GormDataType gorm common data type
+func (Time) GormDataType() string {
+	return string(DataTypeTime)
+}
+
+// GormDBDataType gorm db data type, required as the common
+// data type "time" is used by gorm for all time.Time fields
+func (Time) GormDBDataType(db *gorm.DB, field *schema.Field) string {
+	if dataType, ok := field.TagSettings["TYPE"]; ok {
+		return dataType
+	}
+	return string(DataTypeTime)
+}
+
+// Scan implements the database/sql/Scanner interface
+func (t *Time) Scan(src interface{}) error {
+	v, err := scanTime(src)
+	*t = Time(v)
+	return err
+}
+
+// Value implements the database/sql/Valuer interface
+func (t Time) Value() (driver.Value, error) {
+	h, m, s := time.Time(t).Clock()
+	return time.Date(1, 1, 1, h, m, s, 0, time.UTC), nil
+}
+
+func (t Time) String() string {
+	return time.Time(t).Format(timeLayout)
+}
+
+// MarshalJSON encodes the time as "15:04:05"
+func (t Time) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(t.String())), nil
+}
+
+// UnmarshalJSON decodes the time from "15:04:05"
+func (t *Time) UnmarshalJSON(b []byte) error {
+	v, err := unmarshalTime(b, timeLayout)
+	*t = Time(v)
+	return err
+}
+
+// SecondDate is a SECONDDATE model field, fractional seconds are dropped
+type SecondDate time.Time
+
+// GormDataType gorm common data type
+func (SecondDate) GormDataType() string {
+	return string(DataTypeSecondDate)
+}
+
+// Scan implements the database/sql/Scanner interface
+func (d *SecondDate) Scan(src interface{}) error {
+	t, err := scanTime(src)
+	*d = SecondDate(t)
+	return err
+}
+
+// Value implements the database/sql/Valuer interface
+func (d SecondDate) Value() (driver.Value, error) {
+	return time.Time(d).UTC().Truncate(time.Second), nil
+}
+
+func (d SecondDate) String() string {
+	return time.Time(d).Format(secondDateLayout)
+}
+
+// MarshalJSON encodes the date and time as RFC 3339
+func (d SecondDate) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(d.String())), nil
+}
+
+// UnmarshalJSON decodes the date and time from RFC 3339
+func (d *SecondDate) UnmarshalJSON(b []byte) error {
+	t, err := unmarshalTime(b, secondDateLayout)
+	*d = SecondDate(t)
+	return err
+}
+
+func scanTime(src interface{}) (time.Time, error) {
+	switch src := src.(type) {
+	case nil:
+		return time.Time{}, nil
+	case time.Time:
+		return src, nil
+	}
+	return time.Time{}, fmt.Errorf("time: invalid scan type %T", src)
+}
+
+func unmarshalTime(b []byte, layout string) (time.Time, error) {
+	if string(b) == "null" {
+		return time.Time{}, nil
+	}
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Parse(layout, s)
+}
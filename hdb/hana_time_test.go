@@ -0,0 +1,62 @@
+package hdb
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeTypes(t *testing.T) {
+	type Event struct {
+		ID        uint
+		Day       Date
+		At        Time
+		Scheduled *SecondDate
+		Logged    time.Time
+		Legacy    time.Time `gorm:"type:seconddate"`
+	}
+
+	db := newDryRunDB(t)
+	stmt := db.Model(&Event{}).Statement
+	assert.Nil(t, stmt.Parse(&Event{}))
+
+	m := db.Migrator().(Migrator)
+	for name, dataType := range map[string]string{
+		"Day":       "date",
+		"At":        "time",
+		"Scheduled": "seconddate",
+		"Logged":    "timestamp",
+		"Legacy":    "seconddate",
+	} {
+		assert.Equal(t, dataType, m.FullDataTypeOf(stmt.Schema.LookUpField(name)).SQL, name)
+	}
+
+	berlin := time.FixedZone("CET", 3600)
+	local := time.Date(2022, 3, 4, 0, 30, 15, 123456789, berlin)
+
+	value, err := Date(local).Value()
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2022, 3, 4, 0, 0, 0, 0, time.UTC), value)
+
+	value, err = Time(local).Value()
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(1, 1, 1, 0, 30, 15, 0, time.UTC), value)
+
+	value, err = SecondDate(local).Value()
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2022, 3, 3, 23, 30, 15, 0, time.UTC), value)
+
+	var day Date
+	assert.Nil(t, day.Scan(time.Date(2022, 3, 4, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, "2022-03-04", day.String())
+	assert.NotNil(t, day.Scan("2022-03-04"))
+
+	b, err := json.Marshal(Event{Day: Date(local), At: Time(local)})
+	assert.Nil(t, err)
+	var event Event
+	assert.Nil(t, json.Unmarshal(b, &event))
+	assert.Equal(t, "2022-03-04", event.Day.String())
+	assert.Equal(t, "00:30:15", event.At.String())
+}
@@ -1,6 +1,8 @@
 package hdb
 
 import (
+	"database/sql"
+	"fmt"
 	"strings"
 
 	"gorm.io/gorm"
@@ -35,8 +37,62 @@ func isNumericDataType(datatypeName string) bool {
 	return false
 }
 
+var hanaTemporalTypes = []string{
+	"date",
+	"time",
+	"seconddate",
+	"timestamp",
+}
+
+// hanaLengthTypes are the types which have a length in characters or bytes
+var hanaLengthTypes = []string{
+	"nvarchar",
+	"varchar",
+	"nchar",
+	"char",
+	"varbinary",
+	"binary",
+	"alphanum",
+	"shorttext",
+}
+
+func isDataTypeOf(datatypeName string, types []string) bool {
+	lDataTypeName := strings.ToLower(datatypeName)
+	for _, aType := range types {
+		if lDataTypeName == aType {
+			return true
+		}
+	}
+	return false
+}
+
+func isDecimalDataType(datatypeName string) bool {
+	return isDataTypeOf(datatypeName, hanaDynamicPrecisionNumericTypes)
+}
+
+func isTemporalDataType(datatypeName string) bool {
+	return isDataTypeOf(datatypeName, hanaTemporalTypes)
+}
+
+func hasLength(datatypeName string) bool {
+	return isDataTypeOf(datatypeName, hanaLengthTypes)
+}
+
+// columnTypeOf returns the column type of a catalog column like ALPHANUM(10) or DECIMAL(15, 2)
+func columnTypeOf(datatypeName string, length, scale sql.NullInt64) string {
+	switch {
+	case hasLength(datatypeName) && length.Valid:
+		return fmt.Sprintf("%s(%d)", datatypeName, length.Int64)
+	case isDecimalDataType(datatypeName) && length.Valid && scale.Valid:
+		return fmt.Sprintf("%s(%d, %d)", datatypeName, length.Int64, scale.Int64)
+	}
+	return datatypeName
+}
+
 func RegisterCallbacks(db *gorm.DB) {
 	db.Callback().Create().Replace("gorm:create", hanaCreateCallback)
+	db.Callback().Create().Before("gorm:create").Register("hdb:uuid", hanaUUIDCallback)
+	db.Callback().Query().Replace("gorm:query", hanaQueryCallback)
 }
 
 func hanaCreateCallback(db *gorm.DB) {
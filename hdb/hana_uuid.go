@@ -0,0 +1,204 @@
+package hdb
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// The storage format of a UUID is chosen by its type: UUID is stored as NVARCHAR(36)
+// in canonical text form like SAP CAP does, BinaryUUID as VARBINARY(16) like SYSUUID.
+//
+// Zero UUID primary keys are generated on create with RegisterCallbacks. Keys
+// with a database default value like `gorm:"primaryKey;default:SYSUUID"` are
+// generated by the database, the default is selected from DUMMY for each record
+// before the insert, so the key of a created record is known like for keys
+// generated on the client with uuid.New(). In dry run mode such keys are left
+// to the default of the insert.
+
+// UUID is a UUID model field stored as NVARCHAR(36)
+type UUID uuid.UUID
+
+// NewUUID returns a random UUID
+func NewUUID() UUID {
+	return UUID(uuid.New())
+}
+
+// ParseUUID parses a UUID in text form
+func ParseUUID(s string) (UUID, error) {
+	u, err := uuid.Parse(s)
+	return UUID(u), err
+}
+
+func (u UUID) String() string {
+	return uuid.UUID(u).String()
+}
+
+// IsZero reports whether u is the nil UUID
+func (u UUID) IsZero() bool {
+	return u == UUID{}
+}
+
+// GormDataType gorm common data type
+func (UUID) GormDataType() string {
+	return string(schema.String)
+}
+
+// GormDBDataType gorm db data type
+func (UUID) GormDBDataType(db *gorm.DB, field *schema.Field) string {
+	if dataType, ok := field.TagSettings["TYPE"]; ok {
+		return dataType
+	}
+	return "nvarchar(36)"
+}
+
+// Scan implements the database/sql/Scanner interface
+func (u *UUID) Scan(src interface{}) error {
+	v, err := scanUUID(src)
+	*u = UUID(v)
+	return err
+}
+
+// Value implements the database/sql/Valuer interface
+func (u UUID) Value() (driver.Value, error) {
+	return u.String(), nil
+}
+
+// MarshalText implements the encoding.TextMarshaler interface
+func (u UUID) MarshalText() ([]byte, error) {
+	return uuid.UUID(u).MarshalText()
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface
+func (u *UUID) UnmarshalText(b []byte) error {
+	return (*uuid.UUID)(u).UnmarshalText(b)
+}
+
+func (u *UUID) generate() interface{} {
+	*u = NewUUID()
+	return u
+}
+
+// BinaryUUID is a UUID model field stored as VARBINARY(16)
+type BinaryUUID uuid.UUID
+
+// NewBinaryUUID returns a random UUID
+func NewBinaryUUID() BinaryUUID {
+	return BinaryUUID(uuid.New())
+}
+
+func (u BinaryUUID) String() string {
+	return uuid.UUID(u).String()
+}
+
+// IsZero reports whether u is the nil UUID
+func (u BinaryUUID) IsZero() bool {
+	return u == BinaryUUID{}
+}
+
+// GormDataType gorm common data type
+func (BinaryUUID) GormDataType() string {
+	return string(schema.Bytes)
+}
+
+// GormDBDataType gorm db data type
+func (BinaryUUID) GormDBDataType(db *gorm.DB, field *schema.Field) string {
+	if dataType, ok := field.TagSettings["TYPE"]; ok {
+		return dataType
+	}
+	return "varbinary(16)"
+}
+
+// Scan implements the database/sql/Scanner interface
+func (u *BinaryUUID) Scan(src interface{}) error {
+	v, err := scanUUID(src)
+	*u = BinaryUUID(v)
+	return err
+}
+
+// Value implements the database/sql/Valuer interface
+func (u BinaryUUID) Value() (driver.Value, error) {
+	return u[:], nil
+}
+
+// MarshalText implements the encoding.TextMarshaler interface
+func (u BinaryUUID) MarshalText() ([]byte, error) {
+	return uuid.UUID(u).MarshalText()
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface
+func (u *BinaryUUID) UnmarshalText(b []byte) error {
+	return (*uuid.UUID)(u).UnmarshalText(b)
+}
+
+func (u *BinaryUUID) generate() interface{} {
+	*u = NewBinaryUUID()
+	return u
+}
+
+// scanUUID accepts the binary and the text form of a UUID
+func scanUUID(src interface{}) (uuid.UUID, error) {
+	switch src := src.(type) {
+	case nil:
+		return uuid.Nil, nil
+	case []byte:
+		if len(src) == 16 {
+			return uuid.FromBytes(src)
+		}
+		return uuid.ParseBytes(src)
+	case string:
+		return uuid.Parse(src)
+	}
+	return uuid.Nil, fmt.Errorf("uuid: invalid scan type %T", src)
+}
+
+type uuidGenerator interface {
+	generate() interface{}
+}
+
+// hanaUUIDCallback generates zero UUID primary keys of created records
+func hanaUUIDCallback(db *gorm.DB) {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return
+	}
+
+	for _, field := range db.Statement.Schema.PrimaryFields {
+		if _, ok := reflect.New(field.IndirectFieldType).Interface().(uuidGenerator); !ok {
+			continue
+		}
+
+		switch db.Statement.ReflectValue.Kind() {
+		case reflect.Slice, reflect.Array:
+			for i := 0; i < db.Statement.ReflectValue.Len(); i++ {
+				generateUUID(db, field, reflect.Indirect(db.Statement.ReflectValue.Index(i)))
+			}
+		case reflect.Struct:
+			generateUUID(db, field, db.Statement.ReflectValue)
+		}
+	}
+}
+
+func generateUUID(db *gorm.DB, field *schema.Field, value reflect.Value) {
+	if _, isZero := field.ValueOf(db.Statement.Context, value); !isZero {
+		return
+	}
+
+	generator := reflect.New(field.IndirectFieldType).Interface().(uuidGenerator)
+	if !field.HasDefaultValue || field.DefaultValue == "" {
+		db.AddError(field.Set(db.Statement.Context, value, generator.generate()))
+		return
+	}
+	if db.DryRun {
+		return
+	}
+	row := db.Statement.ConnPool.QueryRowContext(db.Statement.Context, "SELECT "+field.DefaultValue+" FROM DUMMY")
+	if err := row.Scan(generator); err != nil {
+		db.AddError(fmt.Errorf("uuid: default of %s: %w", field.Name, err))
+		return
+	}
+	db.AddError(field.Set(db.Statement.Context, value, generator))
+}
@@ -0,0 +1,119 @@
+package hdb
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"gorm.io/driver/hana/hdb/hanatest"
+)
+
+func TestUUID(t *testing.T) {
+	type Book struct {
+		ID       UUID `gorm:"primaryKey"`
+		Title    string
+		AuthorID *BinaryUUID
+	}
+	type Page struct {
+		ID     BinaryUUID `gorm:"primaryKey;default:SYSUUID"`
+		BookID UUID
+	}
+
+	db := newDryRunDB(t)
+	RegisterCallbacks(db)
+
+	stmt := db.Model(&Book{}).Statement
+	assert.Nil(t, stmt.Parse(&Book{}))
+	m := db.Migrator().(Migrator)
+	assert.Equal(t, "nvarchar(36)", m.FullDataTypeOf(stmt.Schema.LookUpField("ID")).SQL)
+	assert.Equal(t, "varbinary(16)", m.FullDataTypeOf(stmt.Schema.LookUpField("AuthorID")).SQL)
+
+	books := []Book{{Title: "a"}, {Title: "b"}}
+	result := db.Create(&books)
+	assert.Nil(t, result.Error)
+	assert.False(t, books[0].ID.IsZero())
+	assert.False(t, books[1].ID.IsZero())
+	assert.NotEqual(t, books[0].ID, books[1].ID)
+	assert.Contains(t, result.Statement.Vars, books[0].ID)
+
+	// keys with database default are selected from the database, in dry run
+	// mode they are left to the default of the insert
+	page := Page{BookID: books[0].ID}
+	create := db.Create(&page).Statement
+	assert.Nil(t, create.Error)
+	assert.True(t, page.ID.IsZero())
+	assert.Equal(t, `INSERT INTO "pages" ("book_id") VALUES (?)`, create.SQL.String())
+
+	// explicit keys are kept
+	explicit := Page{ID: NewBinaryUUID()}
+	id := explicit.ID
+	assert.Nil(t, db.Create(&explicit).Error)
+	assert.Equal(t, id, explicit.ID)
+
+	where := db.Where(&Book{ID: books[1].ID}).Find(&[]Book{}).Statement
+	assert.Equal(t, `SELECT * FROM "books" WHERE "books"."id" = ?`, where.SQL.String())
+	value, err := books[1].ID.Value()
+	assert.Nil(t, err)
+	assert.Equal(t, books[1].ID.String(), value)
+
+	var scanned BinaryUUID
+	assert.Nil(t, scanned.Scan(books[1].ID[:]))
+	assert.Equal(t, books[1].ID.String(), scanned.String())
+	assert.Nil(t, scanned.Scan(books[1].ID.String()))
+	assert.Equal(t, books[1].ID.String(), scanned.String())
+
+	b, err := json.Marshal(books[0])
+	assert.Nil(t, err)
+	var decoded Book
+	assert.Nil(t, json.Unmarshal(b, &decoded))
+	assert.Equal(t, books[0].ID, decoded.ID)
+}
+
+func TestUUIDDatabaseDefault(t *testing.T) {
+	type Page struct {
+		ID     BinaryUUID `gorm:"primaryKey;default:SYSUUID"`
+		BookID UUID       `gorm:"default:SYSUUID"`
+		Number int
+	}
+
+	id, bookID := NewBinaryUUID(), NewUUID()
+	script := hanatest.NewScript()
+	script.Expect(`SELECT SYSUUID FROM DUMMY`).
+		ReturnsColumns(hanatest.Column{Name: "SYSUUID", Type: hanatest.VarBinary, Length: 16}).
+		ReturnsRow(id[:])
+	script.Expect(`INSERT INTO "pages" ("number","id","book_id") VALUES (?,?,?)`).
+		WithArgs(1, id[:], bookID.String()).
+		ReturnsRowsAffected(1)
+	// client side generation for keys without default
+	script.Expect(`INSERT INTO "books" ("id","title") VALUES (?,?)`).
+		WithArgs(hanatest.Any(hanatest.NVarchar), "a").
+		ReturnsRowsAffected(1)
+
+	srv := hanatest.NewServer(script)
+	defer srv.Close()
+	db, err := gorm.Open(Open(srv.DSN), &gorm.Config{Logger: logger.Discard})
+	if !assert.Nil(t, err) {
+		return
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		defer sqlDB.Close()
+	}
+	RegisterCallbacks(db)
+
+	// only primary keys are generated, the database default of other fields
+	// applies on insert
+	page := Page{BookID: bookID, Number: 1}
+	assert.Nil(t, db.Create(&page).Error)
+	assert.Equal(t, id, page.ID)
+
+	book := struct {
+		ID    UUID `gorm:"primaryKey"`
+		Title string
+	}{Title: "a"}
+	assert.Nil(t, db.Table("books").Create(&book).Error)
+	assert.False(t, book.ID.IsZero())
+	assert.Nil(t, script.ExpectationsWereMet())
+}
@@ -0,0 +1,203 @@
+package hdb
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// DataTypeRealVector vector of 32 bit floating point numbers
+const DataTypeRealVector schema.DataType = "real_vector"
+
+// Vectors are written in the binary vector format with TO_REAL_VECTOR, the
+// dimension of the column is set by the `dim` tag:
+//
+//	type Chunk struct {
+//		Text      string
+//		Embedding hdb.Vector `gorm:"dim:1536"`
+//	}
+//
+//	db.Scopes(hdb.VectorSearch{Column: "embedding", Vector: query, K: 5}.Scope).Find(&chunks)
+
+// ErrInvalidVector is returned for vector values which are no valid binary or text vector
+var ErrInvalidVector = errors.New("invalid vector")
+
+// VectorMetric is the measure of a vector search
+type VectorMetric int
+
+const (
+	// CosineSimilarityMetric orders by descending COSINE_SIMILARITY
+	CosineSimilarityMetric VectorMetric = iota
+	// L2DistanceMetric orders by ascending L2DISTANCE
+	L2DistanceMetric
+)
+
+// Vector is a REAL_VECTOR model field
+type Vector []float32
+
+// GormDataType gorm common data type
+func (Vector) GormDataType() string {
+	return string(DataTypeRealVector)
+}
+
+// GormDBDataType gorm db data type
+func (Vector) GormDBDataType(db *gorm.DB, field *schema.Field) string {
+	if dataType, ok := field.TagSettings["TYPE"]; ok {
+		return dataType
+	}
+	if dim, ok := field.TagSettings["DIM"]; ok {
+		return fmt.Sprintf("%s(%s)", DataTypeRealVector, dim)
+	}
+	return string(DataTypeRealVector)
+}
+
+// GormValue writes the vector in binary format, a nil vector as NULL
+func (v Vector) GormValue(ctx context.Context, db *gorm.DB) clause.Expr {
+	if v == nil {
+		return clause.Expr{SQL: "NULL"}
+	}
+	return clause.Expr{SQL: "TO_REAL_VECTOR(?)", Vars: []interface{}{bytesValue(v.Binary())}}
+}
+
+// Binary returns the binary vector format, the little endian dimension followed
+// by the little endian components
+func (v Vector) Binary() []byte {
+	b := make([]byte, 4+4*len(v))
+	binary.LittleEndian.PutUint32(b, uint32(len(v)))
+	for i, f := range v {
+		binary.LittleEndian.PutUint32(b[4+4*i:], math.Float32bits(f))
+	}
+	return b
+}
+
+// String returns the text vector format, e.g. [1,2.5,3]
+func (v Vector) String() string {
+	var builder strings.Builder
+	builder.WriteByte('[')
+	for i, f := range v {
+		if i > 0 {
+			builder.WriteByte(',')
+		}
+		builder.WriteString(strconv.FormatFloat(float64(f), 'g', -1, 32))
+	}
+	builder.WriteByte(']')
+	return builder.String()
+}
+
+// Scan implements the database/sql/Scanner interface, the
+// vector is read from the binary or the text vector format
+func (v *Vector) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case nil:
+		*v = nil
+		return nil
+	case interface{ SetWriter(w io.Writer) error }:
+		var buffer bytes.Buffer
+		if err := src.SetWriter(&buffer); err != nil {
+			return err
+		}
+		return v.Scan(buffer.Bytes())
+	case []byte:
+		if len(src) > 0 && src[0] == '[' {
+			return v.Scan(string(src))
+		}
+		return v.decodeBinary(src)
+	case string:
+		return v.decodeText(src)
+	}
+	return fmt.Errorf("vector: invalid scan type %T", src)
+}
+
+func (v *Vector) decodeBinary(b []byte) error {
+	if len(b) < 4 {
+		return ErrInvalidVector
+	}
+	dim := binary.LittleEndian.Uint32(b)
+	if uint64(len(b)) != 4+4*uint64(dim) {
+		return fmt.Errorf("%w: %d bytes for dimension %d", ErrInvalidVector, len(b), dim)
+	}
+
+	vector := make(Vector, dim)
+	for i := range vector {
+		vector[i] = math.Float32frombits(binary.LittleEndian.Uint32(b[4+4*i:]))
+	}
+	*v = vector
+	return nil
+}
+
+func (v *Vector) decodeText(s string) error {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "[") || !strings.HasSuffix(s, "]") {
+		return fmt.Errorf("%w: %q", ErrInvalidVector, s)
+	}
+
+	vector := Vector{}
+	if s = strings.TrimSpace(s[1 : len(s)-1]); s != "" {
+		for _, component := range strings.Split(s, ",") {
+			f, err := strconv.ParseFloat(strings.TrimSpace(component), 32)
+			if err != nil {
+				return fmt.Errorf("%w: %v", ErrInvalidVector, err)
+			}
+			vector = append(vector, float32(f))
+		}
+	}
+	*v = vector
+	return nil
+}
+
+// CosineSimilarity is the cosine similarity of the vector of column and v, from -1 to 1
+func CosineSimilarity(column string, v Vector) clause.Expression {
+	return vectorFunction("COSINE_SIMILARITY", column, v)
+}
+
+// L2Distance is the euclidean distance of the vector of column and v
+func L2Distance(column string, v Vector) clause.Expression {
+	return vectorFunction("L2DISTANCE", column, v)
+}
+
+func vectorFunction(name, column string, v Vector) clause.Expression {
+	return clause.Expr{SQL: name + "(?, ?)", Vars: []interface{}{clause.Column{Name: column}, v}}
+}
+
+// VectorSearch selects the K rows whose vector of Column is most similar to Vector
+type VectorSearch struct {
+	Column string
+	Vector Vector
+	Metric VectorMetric
+	K      int
+}
+
+// Score is the similarity or distance measured by the search, e.g. to select it
+//
+//	db.Select("*, ? AS score", search.Score())
+func (s VectorSearch) Score() clause.Expression {
+	if s.Metric == L2DistanceMetric {
+		return L2Distance(s.Column, s.Vector)
+	}
+	return CosineSimilarity(s.Column, s.Vector)
+}
+
+// Scope orders by the score, most similar first, and limits the query with TOP K.
+// The order replaces previous orders of db.
+func (s VectorSearch) Scope(db *gorm.DB) *gorm.DB {
+	order := "? DESC"
+	if s.Metric == L2DistanceMetric {
+		order = "?"
+	}
+	db = db.Clauses(clause.OrderBy{Expression: clause.Expr{SQL: order, Vars: []interface{}{s.Score()}}})
+
+	if s.K > 0 {
+		db = db.Clauses(Top{Limit: s.K})
+	}
+	return db
+}
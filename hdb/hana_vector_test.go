@@ -0,0 +1,53 @@
+package hdb
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVector(t *testing.T) {
+	type Chunk struct {
+		ID        uint
+		Text      string
+		Embedding Vector `gorm:"dim:3"`
+		Draft     Vector
+	}
+
+	db := newDryRunDB(t)
+	stmt := db.Model(&Chunk{}).Statement
+	assert.Nil(t, stmt.Parse(&Chunk{}))
+	m := db.Migrator().(Migrator)
+	assert.Equal(t, "real_vector(3)", m.FullDataTypeOf(stmt.Schema.LookUpField("Embedding")).SQL)
+	assert.Equal(t, "real_vector", m.FullDataTypeOf(stmt.Schema.LookUpField("Draft")).SQL)
+
+	embedding := Vector{1, 0.5, -2}
+	assert.Equal(t, "03000000"+"0000803f"+"0000003f"+"000000c0", hex.EncodeToString(embedding.Binary()))
+	assert.Equal(t, "[1,0.5,-2]", embedding.String())
+
+	create := db.Create(&Chunk{Text: "a", Embedding: embedding}).Statement
+	assert.Equal(t, `INSERT INTO "chunks" ("text","embedding","draft") VALUES (?,TO_REAL_VECTOR(?),NULL)`, create.SQL.String())
+	assert.Len(t, create.Vars, 2)
+
+	search := VectorSearch{Column: "embedding", Vector: embedding, K: 5}
+	query := db.Scopes(search.Scope).Find(&[]Chunk{}).Statement
+	assert.Equal(t, `SELECT TOP 5 * FROM "chunks" ORDER BY COSINE_SIMILARITY("embedding", TO_REAL_VECTOR(?)) DESC `, query.SQL.String())
+
+	search.Metric = L2DistanceMetric
+	query = db.Select("*, ? AS score", search.Score()).Where("text <> ?", "").Scopes(search.Scope).Find(&[]Chunk{}).Statement
+	assert.Equal(t, `SELECT TOP 5 *, L2DISTANCE("embedding", TO_REAL_VECTOR(?)) AS score FROM "chunks" WHERE text <> ? ORDER BY L2DISTANCE("embedding", TO_REAL_VECTOR(?)) `, query.SQL.String())
+
+	var scanned Vector
+	assert.Nil(t, scanned.Scan(embedding.Binary()))
+	assert.Equal(t, embedding, scanned)
+	assert.Nil(t, scanned.Scan(" [ 3, 4.25 ] "))
+	assert.Equal(t, Vector{3, 4.25}, scanned)
+	assert.Nil(t, scanned.Scan([]byte("[]")))
+	assert.Equal(t, Vector{}, scanned)
+	assert.Nil(t, scanned.Scan(nil))
+	assert.Nil(t, scanned)
+
+	assert.ErrorIs(t, scanned.Scan([]byte{2, 0, 0, 0, 1}), ErrInvalidVector)
+	assert.ErrorIs(t, scanned.Scan("[1,x]"), ErrInvalidVector)
+}
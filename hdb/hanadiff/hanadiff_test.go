@@ -0,0 +1,41 @@
+package hanadiff
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	hdb "gorm.io/driver/hana/hdb"
+)
+
+type diffUser struct {
+	ID   uint   `gorm:"primaryKey;autoIncrement:false"`
+	Name string `gorm:"size:100"`
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	snapshotPath := filepath.Join(dir, "snapshot.json")
+	snapshot := &hdb.Snapshot{Schema: "SALES", Tables: []hdb.CatalogTable{{
+		Name:       "diff_users",
+		Columns:    []hdb.CatalogColumn{{Name: "id", DataType: "BIGINT"}},
+		PrimaryKey: []string{"id"},
+	}}}
+	if !assert.Nil(t, snapshot.Save(snapshotPath)) {
+		return
+	}
+
+	var stdout strings.Builder
+	downPath := filepath.Join(dir, "down.sql")
+	assert.Nil(t, Run([]string{"-snapshot", snapshotPath, "-down", downPath}, &stdout, &diffUser{}))
+	assert.Equal(t, "ALTER TABLE \"diff_users\" ADD (\"name\" nvarchar(100));\n", stdout.String())
+
+	down, err := ioutil.ReadFile(downPath)
+	assert.Nil(t, err)
+	assert.Equal(t, "ALTER TABLE \"diff_users\" DROP (\"name\");\n", string(down))
+
+	assert.NotNil(t, Run([]string{"-dsn", ""}, &stdout, &diffUser{}))
+}
@@ -0,0 +1,95 @@
+// Package hanahdi is a command line tool which writes the HDI design-time
+// artifacts of gorm models, so they are deployed into SAP HDI containers
+// instead of being migrated with DDL. The models are compiled into a small
+// command of the application:
+//
+//	func main() {
+//		hanahdi.Main(&models.User{}, &models.Order{})
+//	}
+//
+// which writes .hdbtable and .hdbindex files, and the .hdbsequence and
+// .hdbview files of the sequences and views of a catalog snapshot:
+//
+//	go run ./cmd/hdi -o db/src
+//	go run ./cmd/hdi -upper -snapshot objects.yaml -o db/src
+//
+// The .hdiconfig of the directory has to enable the hdbtable, hdbindex,
+// hdbsequence and hdbview plugins.
+package hanahdi
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	hdb "gorm.io/driver/hana/hdb"
+)
+
+// Main runs the tool with the command line arguments and exits on errors
+func Main(models ...interface{}) {
+	if err := Run(os.Args[1:], os.Stdout, models...); err != nil {
+		fmt.Fprintln(os.Stderr, "hanahdi:", err)
+		os.Exit(1)
+	}
+}
+
+// Run writes the artifacts of models for the arguments args and lists the
+// written files on stdout
+func Run(args []string, stdout io.Writer, models ...interface{}) error {
+	flags := flag.NewFlagSet("hanahdi", flag.ContinueOnError)
+	var (
+		output       = flags.String("o", ".", "output `directory` of the artifacts")
+		snapshotPath = flags.String("snapshot", "", "write the sequences and views of the catalog snapshot `file`")
+		upper        = flags.Bool("upper", false, "name tables and columns in upper case with hdb.NamingStrategy")
+	)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	config := hdb.Config{CatalogSnapshot: &hdb.Snapshot{}}
+	if *upper {
+		config.NamingStrategy = hdb.NamingStrategy{}
+	}
+	db, err := gorm.Open(hdb.New(config), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		return err
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		defer sqlDB.Close()
+	}
+
+	artifacts, err := db.Migrator().(hdb.Migrator).HDIArtifacts(models...)
+	if err != nil {
+		return err
+	}
+	if *snapshotPath != "" {
+		snapshot, err := hdb.LoadSnapshot(*snapshotPath)
+		if err != nil {
+			return err
+		}
+		for _, sequence := range snapshot.Sequences {
+			artifacts = append(artifacts, sequence.HDIArtifact())
+		}
+		for _, view := range snapshot.Views {
+			artifacts = append(artifacts, view.HDIArtifact())
+		}
+	}
+
+	if err := os.MkdirAll(*output, 0755); err != nil {
+		return err
+	}
+	for _, artifact := range artifacts {
+		path := filepath.Join(*output, artifact.Name)
+		if err := ioutil.WriteFile(path, []byte(artifact.Content), 0644); err != nil {
+			return err
+		}
+		fmt.Fprintln(stdout, path)
+	}
+	return nil
+}
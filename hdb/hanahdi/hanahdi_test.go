@@ -0,0 +1,50 @@
+package hanahdi
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	hdb "gorm.io/driver/hana/hdb"
+)
+
+type hdiUser struct {
+	ID   uint   `gorm:"primaryKey;autoIncrement:false"`
+	Name string `gorm:"size:100"`
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	snapshotPath := filepath.Join(dir, "objects.yaml")
+	snapshot := &hdb.Snapshot{
+		Sequences: []hdb.CatalogSequence{{Name: "USER_NO", StartValue: 1}},
+		Views:     []hdb.CatalogView{{Name: "NAMED_USERS", Definition: `SELECT * FROM "HDI_USERS" WHERE "NAME" IS NOT NULL`}},
+	}
+	if !assert.Nil(t, snapshot.Save(snapshotPath)) {
+		return
+	}
+
+	var stdout strings.Builder
+	output := filepath.Join(dir, "src")
+	if !assert.Nil(t, Run([]string{"-upper", "-snapshot", snapshotPath, "-o", output}, &stdout, &hdiUser{})) {
+		return
+	}
+	assert.Equal(t, strings.Join([]string{
+		filepath.Join(output, "HDI_USERS.hdbtable"),
+		filepath.Join(output, "USER_NO.hdbsequence"),
+		filepath.Join(output, "NAMED_USERS.hdbview"),
+	}, "\n")+"\n", stdout.String())
+
+	table, err := ioutil.ReadFile(filepath.Join(output, "HDI_USERS.hdbtable"))
+	assert.Nil(t, err)
+	assert.Equal(t, "COLUMN TABLE \"HDI_USERS\" (\n  \"ID\" bigint,\n  \"NAME\" nvarchar(100),\n  PRIMARY KEY (\"ID\")\n)\n", string(table))
+
+	sequence, err := ioutil.ReadFile(filepath.Join(output, "USER_NO.hdbsequence"))
+	assert.Nil(t, err)
+	assert.Equal(t, "SEQUENCE \"USER_NO\" START WITH 1 INCREMENT BY 1 MINVALUE 0 NO MAXVALUE NO CYCLE\n", string(sequence))
+
+	assert.NotNil(t, Run([]string{"-snapshot", filepath.Join(dir, "missing.json")}, &stdout, &hdiUser{}))
+}
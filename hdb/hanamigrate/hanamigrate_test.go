@@ -0,0 +1,60 @@
+package hanamigrate
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	hdb "gorm.io/driver/hana/hdb"
+)
+
+func TestParseCommand(t *testing.T) {
+	for _, test := range []struct {
+		args    []string
+		command string
+		version int64
+		err     string
+	}{
+		{args: []string{"up"}, command: "up"},
+		{args: []string{"up", "12"}, command: "up", version: 12},
+		{args: []string{"down"}, command: "down"},
+		{args: []string{"status"}, command: "status"},
+		{args: []string{"baseline", "3"}, command: "baseline", version: 3},
+		{args: []string{"baseline"}, err: "baseline requires a version"},
+		{args: []string{"up", "x"}, err: `invalid version "x"`},
+		{args: []string{"down", "2"}, err: `invalid command ["down" "2"]`},
+		{args: nil, err: "missing command"},
+	} {
+		command, version, err := parseCommand(test.args)
+		if test.err != "" {
+			assert.EqualError(t, err, test.err, test.args)
+			continue
+		}
+		assert.Nil(t, err, test.args)
+		assert.Equal(t, test.command, command, test.args)
+		assert.Equal(t, test.version, version, test.args)
+	}
+}
+
+func TestWriteStatus(t *testing.T) {
+	applied := time.Date(2022, 3, 4, 5, 6, 7, 0, time.UTC)
+
+	var builder strings.Builder
+	assert.Nil(t, writeStatus(&builder, []hdb.MigrationStatus{
+		{Version: 1, Description: "create orders", Applied: applied, Baseline: true},
+		{Version: 2, Description: "create items", Applied: applied},
+		{Version: 3, Description: "add note"},
+	}))
+	assert.Equal(t, ""+
+		"VERSION  DESCRIPTION    APPLIED               STATE\n"+
+		"1        create orders  2022-03-04T05:06:07Z  baseline\n"+
+		"2        create items   2022-03-04T05:06:07Z  applied\n"+
+		"3        add note                             pending\n", builder.String())
+}
+
+func TestRunWithoutDSN(t *testing.T) {
+	var builder strings.Builder
+	assert.EqualError(t, Run([]string{"-dsn", "", "status"}, &builder), "-dsn is required")
+}
@@ -0,0 +1,521 @@
+package hanatest
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultSchema is the current schema of a catalog
+const DefaultSchema = "SYSTEM"
+
+// Catalog is an in-memory HANA catalog for migrator tests, it is a
+// driver.Connector answering the catalog queries of hdb.Migrator from
+// declared tables and recording all other statements:
+//
+//	catalog := hanatest.NewCatalog(hanatest.Table{
+//		Name:    "users",
+//		Columns: []hanatest.TableColumn{{Name: "id", DataType: "BIGINT", PrimaryKey: true}},
+//	})
+//	db, err := gorm.Open(hdb.New(hdb.Config{Connector: catalog}), &gorm.Config{})
+//	err = db.AutoMigrate(&User{})
+//	ddl := catalog.DDL()
+//
+// The declared tables are not changed by the recorded statements.
+type Catalog struct {
+	// Schema is the current schema, tables without schema belong to it
+	Schema string
+
+	mu        sync.Mutex
+	tables    []Table
+	sequences []Sequence
+	views     []View
+	ddl       []string
+}
+
+// Table is a table of a catalog
+type Table struct {
+	Schema      string
+	Name        string
+	Comment     string
+	Columns     []TableColumn
+	Indexes     []Index
+	Checks      []Check
+	ForeignKeys []ForeignKey
+}
+
+// Check is a check constraint of a catalog table
+type Check struct {
+	Name      string
+	Condition string
+}
+
+// Index is an index of a catalog table
+type Index struct {
+	Name    string
+	Columns []string
+	Unique  bool
+}
+
+// ForeignKey is a foreign key constraint of a catalog table
+type ForeignKey struct {
+	Name    string
+	Columns []string
+	// ReferencedSchema is the schema of the referenced table, empty for the current schema
+	ReferencedSchema  string
+	ReferencedTable   string
+	ReferencedColumns []string
+	// DeleteRule is the referential action on delete, RESTRICT if empty
+	DeleteRule string
+}
+
+// Sequence is a sequence of a catalog
+type Sequence struct {
+	Schema     string
+	Name       string
+	StartValue int64
+	MinValue   int64
+	MaxValue   int64
+	Increment  int64
+	Cycle      bool
+}
+
+// View is a view of a catalog
+type View struct {
+	Schema     string
+	Name       string
+	Definition string
+	Comment    string
+}
+
+// TableColumn is a column of a catalog table as listed by SYS.TABLE_COLUMNS
+type TableColumn struct {
+	Name string
+	// DataType is the data type name like NVARCHAR or DECIMAL
+	DataType string
+	// Length is the length of character and binary types or the precision of decimals
+	Length int
+	Scale  int
+	// Nullable columns have no NOT NULL constraint
+	Nullable bool
+	// DefaultValue is the default value, empty for none
+	DefaultValue string
+	// Comment is the column comment, empty for none
+	Comment    string
+	PrimaryKey bool
+	// Unique columns have a unique constraint
+	Unique   bool
+	Identity bool
+}
+
+// NewCatalog returns a catalog of tables in DefaultSchema
+func NewCatalog(tables ...Table) *Catalog {
+	c := &Catalog{Schema: DefaultSchema}
+	for _, t := range tables {
+		c.AddTable(t)
+	}
+	return c
+}
+
+// AddTable declares table
+func (c *Catalog) AddTable(table Table) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tables = append(c.tables, table)
+}
+
+// AddSequence declares sequence
+func (c *Catalog) AddSequence(sequence Sequence) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sequences = append(c.sequences, sequence)
+}
+
+// AddView declares view
+func (c *Catalog) AddView(view View) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.views = append(c.views, view)
+}
+
+// DDL returns the recorded statements
+func (c *Catalog) DDL() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.ddl...)
+}
+
+// Reset clears the recorded statements
+func (c *Catalog) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ddl = nil
+}
+
+func (c *Catalog) schemaOf(schemaName string) string {
+	if schemaName == "" {
+		return c.Schema
+	}
+	return schemaName
+}
+
+func (c *Catalog) table(schemaName, tableName string) (Table, bool) {
+	for _, t := range c.tables {
+		if c.schemaOf(t.Schema) == schemaName && t.Name == tableName {
+			return t, true
+		}
+	}
+	return Table{}, false
+}
+
+// Connect implements driver.Connector
+func (c *Catalog) Connect(ctx context.Context) (driver.Conn, error) {
+	return &catalogConn{catalog: c}, nil
+}
+
+// Driver implements driver.Connector
+func (c *Catalog) Driver() driver.Driver {
+	return catalogDriver{catalog: c}
+}
+
+type catalogDriver struct {
+	catalog *Catalog
+}
+
+func (d catalogDriver) Open(name string) (driver.Conn, error) {
+	return &catalogConn{catalog: d.catalog}, nil
+}
+
+// catalogQuery answers a catalog query matching pattern
+type catalogQuery struct {
+	pattern *regexp.Regexp
+	answer  func(c *Catalog, args []string) ([]string, [][]driver.Value)
+}
+
+func count(ok bool) [][]driver.Value {
+	if ok {
+		return [][]driver.Value{{int64(1)}}
+	}
+	return [][]driver.Value{{int64(0)}}
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func boolString(b bool) string {
+	if b {
+		return "TRUE"
+	}
+	return "FALSE"
+}
+
+// lob is a NCLOB column value, which go-hdb returns as writer setter instead of string
+type lob struct {
+	content string
+}
+
+// SetWriter writes the content to w like the lob values of go-hdb
+func (l *lob) SetWriter(w io.Writer) error {
+	_, err := io.WriteString(w, l.content)
+	return err
+}
+
+func nullString(s string) driver.Value {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func indexNames(indexes []Index) []string {
+	var names []string
+	for _, idx := range indexes {
+		names = append(names, idx.Name)
+	}
+	return names
+}
+
+func constraintNames(t Table) []string {
+	var names []string
+	for _, chk := range t.Checks {
+		names = append(names, chk.Name)
+	}
+	for _, fk := range t.ForeignKeys {
+		names = append(names, fk.Name)
+	}
+	return names
+}
+
+var catalogQueries = []catalogQuery{
+	{
+		pattern: regexp.MustCompile(`(?i)^SELECT CURRENT_SCHEMA FROM DUMMY$`),
+		answer: func(c *Catalog, args []string) ([]string, [][]driver.Value) {
+			return []string{"CURRENT_SCHEMA"}, [][]driver.Value{{c.Schema}}
+		},
+	},
+	{
+		pattern: regexp.MustCompile(`(?i)^SELECT TABLE_NAME, COMMENTS FROM SYS\.TABLES\b`),
+		answer: func(c *Catalog, args []string) ([]string, [][]driver.Value) {
+			var tables []Table
+			for _, t := range c.tables {
+				if c.schemaOf(t.Schema) == args[0] {
+					tables = append(tables, t)
+				}
+			}
+			sort.Slice(tables, func(i, j int) bool { return tables[i].Name < tables[j].Name })
+
+			var rows [][]driver.Value
+			for _, t := range tables {
+				rows = append(rows, []driver.Value{t.Name, nullString(t.Comment)})
+			}
+			return []string{"TABLE_NAME", "COMMENTS"}, rows
+		},
+	},
+	{
+		pattern: regexp.MustCompile(`(?i)\bFROM SYS\.TABLES\b`),
+		answer: func(c *Catalog, args []string) ([]string, [][]driver.Value) {
+			_, ok := c.table(args[0], args[1])
+			return []string{"COUNT(1)"}, count(ok)
+		},
+	},
+	{
+		pattern: regexp.MustCompile(`(?i)\bFROM SYS\.TABLE_COLUMNS t LEFT JOIN SYS\.CONSTRAINTS\b`),
+		answer: func(c *Catalog, args []string) ([]string, [][]driver.Value) {
+			columns := []string{"COLUMN_NAME", "IS_NULLABLE", "DATA_TYPE_NAME", "LENGTH", "SCALE",
+				"DEFAULT_VALUE", "COMMENTS", "IS_PRIMARY_KEY", "IS_UNIQUE_KEY"}
+			t, _ := c.table(args[0], args[1])
+			var rows [][]driver.Value
+			for _, col := range t.Columns {
+				// columns without constraint are not joined
+				var primaryKey, unique driver.Value
+				if col.PrimaryKey || col.Unique {
+					primaryKey, unique = boolString(col.PrimaryKey), boolString(col.Unique)
+				}
+				rows = append(rows, []driver.Value{
+					col.Name, boolString(col.Nullable), strings.ToUpper(col.DataType),
+					int64(col.Length), int64(col.Scale),
+					nullString(col.DefaultValue), nullString(col.Comment), primaryKey, unique,
+				})
+			}
+			return columns, rows
+		},
+	},
+	{
+		pattern: regexp.MustCompile(`(?i)^SELECT COLUMN_NAME, DATA_TYPE_NAME\b.*\bFROM SYS\.TABLE_COLUMNS\b`),
+		answer: func(c *Catalog, args []string) ([]string, [][]driver.Value) {
+			columns := []string{"COLUMN_NAME", "DATA_TYPE_NAME", "LENGTH", "SCALE", "IS_NULLABLE",
+				"DEFAULT_VALUE", "COMMENTS", "GENERATION_TYPE"}
+			t, _ := c.table(args[0], args[1])
+			var rows [][]driver.Value
+			for _, col := range t.Columns {
+				var generationType driver.Value
+				if col.Identity {
+					generationType = "BY DEFAULT AS IDENTITY"
+				}
+				rows = append(rows, []driver.Value{
+					col.Name, strings.ToUpper(col.DataType), int64(col.Length), int64(col.Scale),
+					boolString(col.Nullable), nullString(col.DefaultValue), nullString(col.Comment), generationType,
+				})
+			}
+			return columns, rows
+		},
+	},
+	{
+		pattern: regexp.MustCompile(`(?i)\bFROM SYS\.TABLE_COLUMNS\b`),
+		answer: func(c *Catalog, args []string) ([]string, [][]driver.Value) {
+			t, _ := c.table(args[0], args[1])
+			ok := false
+			for _, col := range t.Columns {
+				ok = ok || col.Name == args[2]
+			}
+			return []string{"COUNT(*)"}, count(ok)
+		},
+	},
+	{
+		pattern: regexp.MustCompile(`(?i)\bFROM SYS\.INDEXES\b`),
+		answer: func(c *Catalog, args []string) ([]string, [][]driver.Value) {
+			t, _ := c.table(args[0], args[1])
+			return []string{"COUNT(*)"}, count(contains(indexNames(t.Indexes), args[2]))
+		},
+	},
+	{
+		pattern: regexp.MustCompile(`(?i)^SELECT CONSTRAINT_NAME, COLUMN_NAME, IS_PRIMARY_KEY, CHECK_CONDITION FROM SYS\.CONSTRAINTS\b`),
+		answer: func(c *Catalog, args []string) ([]string, [][]driver.Value) {
+			t, _ := c.table(args[0], args[1])
+			var rows, unique [][]driver.Value
+			for _, chk := range t.Checks {
+				rows = append(rows, []driver.Value{chk.Name, nil, "FALSE", &lob{chk.Condition}})
+			}
+			for _, col := range t.Columns {
+				if col.PrimaryKey {
+					rows = append(rows, []driver.Value{"PK_" + t.Name, col.Name, "TRUE", nil})
+				} else if col.Unique {
+					unique = append(unique, []driver.Value{"UK_" + t.Name + "_" + col.Name, col.Name, "FALSE", nil})
+				}
+			}
+			return []string{"CONSTRAINT_NAME", "COLUMN_NAME", "IS_PRIMARY_KEY", "CHECK_CONDITION"}, append(rows, unique...)
+		},
+	},
+	{
+		pattern: regexp.MustCompile(`(?i)^SELECT INDEX_NAME, COLUMN_NAME, CONSTRAINT FROM SYS\.INDEX_COLUMNS\b`),
+		answer: func(c *Catalog, args []string) ([]string, [][]driver.Value) {
+			t, _ := c.table(args[0], args[1])
+			indexes := append([]Index(nil), t.Indexes...)
+			sort.Slice(indexes, func(i, j int) bool { return indexes[i].Name < indexes[j].Name })
+
+			var rows [][]driver.Value
+			for _, idx := range indexes {
+				var constraint driver.Value
+				if idx.Unique {
+					constraint = "UNIQUE"
+				}
+				for _, col := range idx.Columns {
+					rows = append(rows, []driver.Value{idx.Name, col, constraint})
+				}
+			}
+			return []string{"INDEX_NAME", "COLUMN_NAME", "CONSTRAINT"}, rows
+		},
+	},
+	{
+		pattern: regexp.MustCompile(`(?i)^SELECT CONSTRAINT_NAME, COLUMN_NAME, REFERENCED_SCHEMA_NAME\b`),
+		answer: func(c *Catalog, args []string) ([]string, [][]driver.Value) {
+			columns := []string{"CONSTRAINT_NAME", "COLUMN_NAME", "REFERENCED_SCHEMA_NAME", "REFERENCED_TABLE_NAME",
+				"REFERENCED_COLUMN_NAME", "DELETE_RULE", "UPDATE_RULE"}
+			t, _ := c.table(args[0], args[1])
+			fks := append([]ForeignKey(nil), t.ForeignKeys...)
+			sort.Slice(fks, func(i, j int) bool { return fks[i].Name < fks[j].Name })
+
+			var rows [][]driver.Value
+			for _, fk := range fks {
+				deleteRule := fk.DeleteRule
+				if deleteRule == "" {
+					deleteRule = "RESTRICT"
+				}
+				for i, col := range fk.Columns {
+					rows = append(rows, []driver.Value{
+						fk.Name, col, c.schemaOf(fk.ReferencedSchema), fk.ReferencedTable, fk.ReferencedColumns[i],
+						deleteRule, "RESTRICT",
+					})
+				}
+			}
+			return columns, rows
+		},
+	},
+	{
+		pattern: regexp.MustCompile(`(?i)^SELECT SEQUENCE_NAME\b.*\bFROM SYS\.SEQUENCES\b`),
+		answer: func(c *Catalog, args []string) ([]string, [][]driver.Value) {
+			var sequences []Sequence
+			for _, seq := range c.sequences {
+				if c.schemaOf(seq.Schema) == args[0] {
+					sequences = append(sequences, seq)
+				}
+			}
+			sort.Slice(sequences, func(i, j int) bool { return sequences[i].Name < sequences[j].Name })
+
+			var rows [][]driver.Value
+			for _, seq := range sequences {
+				rows = append(rows, []driver.Value{
+					seq.Name, seq.StartValue, seq.MinValue, seq.MaxValue, seq.Increment, boolString(seq.Cycle),
+				})
+			}
+			return []string{"SEQUENCE_NAME", "START_NUMBER", "MIN_VALUE", "MAX_VALUE", "INCREMENT_BY", "IS_CYCLED"}, rows
+		},
+	},
+	{
+		pattern: regexp.MustCompile(`(?i)^SELECT VIEW_NAME\b.*\bFROM SYS\.VIEWS\b`),
+		answer: func(c *Catalog, args []string) ([]string, [][]driver.Value) {
+			var views []View
+			for _, v := range c.views {
+				if c.schemaOf(v.Schema) == args[0] {
+					views = append(views, v)
+				}
+			}
+			sort.Slice(views, func(i, j int) bool { return views[i].Name < views[j].Name })
+
+			var rows [][]driver.Value
+			for _, v := range views {
+				rows = append(rows, []driver.Value{v.Name, &lob{v.Definition}, nullString(v.Comment)})
+			}
+			return []string{"VIEW_NAME", "DEFINITION", "COMMENTS"}, rows
+		},
+	},
+	{
+		pattern: regexp.MustCompile(`(?i)\bFROM SYS\.CONSTRAINTS\b.*\bSYS\.REFERENTIAL_CONSTRAINTS\b`),
+		answer: func(c *Catalog, args []string) ([]string, [][]driver.Value) {
+			t, _ := c.table(args[0], args[1])
+			return []string{"COUNT(*)"}, count(contains(constraintNames(t), args[4]))
+		},
+	},
+}
+
+var errCatalogPrepare = errors.New("hanatest: catalog connections do not prepare statements")
+
+// catalogConn is a connection to a catalog
+type catalogConn struct {
+	catalog *Catalog
+}
+
+func (conn *catalogConn) Prepare(query string) (driver.Stmt, error) { return nil, errCatalogPrepare }
+func (conn *catalogConn) Close() error                              { return nil }
+func (conn *catalogConn) Begin() (driver.Tx, error)                 { return conn, nil }
+func (conn *catalogConn) Commit() error                             { return nil }
+func (conn *catalogConn) Rollback() error                           { return nil }
+
+func (conn *catalogConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	query = normalizeQuery(query)
+	for _, q := range catalogQueries {
+		if !q.pattern.MatchString(query) {
+			continue
+		}
+		strArgs := make([]string, len(args))
+		for i, arg := range args {
+			strArgs[i] = fmt.Sprint(arg.Value)
+		}
+
+		conn.catalog.mu.Lock()
+		columns, rows := q.answer(conn.catalog, strArgs)
+		conn.catalog.mu.Unlock()
+		return &memoryRows{columns: columns, rows: rows}, nil
+	}
+	return nil, fmt.Errorf("hanatest: unsupported catalog query %q", query)
+}
+
+func (conn *catalogConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if len(args) != 0 {
+		return nil, fmt.Errorf("hanatest: unsupported statement %q with arguments", query)
+	}
+	conn.catalog.mu.Lock()
+	defer conn.catalog.mu.Unlock()
+	conn.catalog.ddl = append(conn.catalog.ddl, query)
+	return driver.ResultNoRows, nil
+}
+
+// memoryRows are rows held in memory
+type memoryRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *memoryRows) Columns() []string { return r.columns }
+func (r *memoryRows) Close() error      { return nil }
+
+func (r *memoryRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
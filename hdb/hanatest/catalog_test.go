@@ -0,0 +1,86 @@
+package hanatest_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	hdb "gorm.io/driver/hana/hdb"
+	"gorm.io/driver/hana/hdb/hanatest"
+)
+
+type catalogUser struct {
+	ID    uint
+	Name  string `gorm:"size:100"`
+	Email string `gorm:"size:200;index"`
+	Age   int
+}
+
+type catalogPet struct {
+	ID   uint
+	Name string `gorm:"size:50"`
+}
+
+func openCatalog(t *testing.T, catalog *hanatest.Catalog) *gorm.DB {
+	db, err := gorm.Open(hdb.New(hdb.Config{Connector: catalog}), &gorm.Config{Logger: logger.Discard})
+	assert.Nil(t, err)
+	return db
+}
+
+func TestCatalogAutoMigrate(t *testing.T) {
+	catalog := hanatest.NewCatalog(hanatest.Table{
+		Name: "catalog_users",
+		Columns: []hanatest.TableColumn{
+			{Name: "id", DataType: "BIGINT", Length: 19, PrimaryKey: true},
+			{Name: "name", DataType: "NVARCHAR", Length: 50, Nullable: true},
+			{Name: "email", DataType: "NVARCHAR", Length: 200, Nullable: true},
+		},
+	})
+	db := openCatalog(t, catalog)
+
+	assert.Nil(t, db.AutoMigrate(&catalogUser{}, &catalogPet{}))
+	assert.Equal(t, []string{
+		`ALTER TABLE "catalog_users" ALTER ("name" nvarchar(100))`,
+		`ALTER TABLE "catalog_users" ADD ("age" bigint)`,
+		`CREATE INDEX "idx_catalog_users_email" ON "catalog_users"("email")`,
+		`CREATE TABLE "catalog_pets" ("id" bigint GENERATED BY DEFAULT AS IDENTITY,"name" nvarchar(50),PRIMARY KEY ("id"))`,
+	}, catalog.DDL())
+}
+
+func TestCatalogMigrator(t *testing.T) {
+	catalog := hanatest.NewCatalog(hanatest.Table{
+		Schema:  "SALES",
+		Name:    "catalog_users",
+		Columns: []hanatest.TableColumn{{Name: "id", DataType: "BIGINT", Length: 19, PrimaryKey: true}},
+		Indexes: []hanatest.Index{{Name: "idx_catalog_users_email", Columns: []string{"email"}}},
+		ForeignKeys: []hanatest.ForeignKey{{
+			Name: "fk_catalog_users_pets", Columns: []string{"id"}, ReferencedTable: "catalog_pets", ReferencedColumns: []string{"id"},
+		}},
+	})
+	db := openCatalog(t, catalog)
+	m := db.Migrator()
+
+	assert.Equal(t, hanatest.DefaultSchema, m.CurrentDatabase())
+	assert.False(t, m.HasTable(&catalogUser{}))
+	assert.True(t, m.HasTable("SALES.catalog_users"))
+	assert.True(t, m.HasColumn("SALES.catalog_users", "id"))
+	assert.False(t, m.HasColumn("SALES.catalog_users", "name"))
+	assert.True(t, m.HasIndex("SALES.catalog_users", "idx_catalog_users_email"))
+	assert.True(t, m.HasConstraint("SALES.catalog_users", "fk_catalog_users_pets"))
+	assert.False(t, m.HasConstraint("SALES.catalog_users", "fk_catalog_users_owner"))
+
+	columnTypes, err := m.ColumnTypes("SALES.catalog_users")
+	assert.Nil(t, err)
+	if assert.Len(t, columnTypes, 1) {
+		primaryKey, _ := columnTypes[0].PrimaryKey()
+		assert.True(t, primaryKey)
+		assert.Equal(t, "BIGINT", columnTypes[0].DatabaseTypeName())
+	}
+
+	assert.Nil(t, m.DropTable("SALES.catalog_users"))
+	assert.Equal(t, []string{`DROP TABLE IF EXISTS "SALES"."catalog_users" CASCADE`}, catalog.DDL())
+	catalog.Reset()
+	assert.Empty(t, catalog.DDL())
+}
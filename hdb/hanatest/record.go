@@ -0,0 +1,596 @@
+package hanatest
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Fixture is the recording of the statements executed in a test run
+type Fixture struct {
+	Statements []RecordedStatement `json:"statements"`
+}
+
+// Statement kinds of recordings
+const (
+	KindExec  = "exec"
+	KindQuery = "query"
+)
+
+// RecordedStatement is an executed statement and its result
+type RecordedStatement struct {
+	Kind    string    `json:"kind"`
+	Query   string    `json:"query"`
+	Args    []Value   `json:"args,omitempty"`
+	Columns []string  `json:"columns,omitempty"`
+	Rows    [][]Value `json:"rows,omitempty"`
+	// RowsAffected is missing for statements without affected rows like DDL
+	RowsAffected *int64 `json:"rowsAffected,omitempty"`
+	// Error is the error text of failed statements
+	Error string `json:"error,omitempty"`
+}
+
+// Value is a recorded argument or column value
+type Value struct {
+	// Type is null, int64, float64, bool, string, bytes, time or decimal,
+	// arguments of other types are recorded with their Go type
+	Type  string `json:"type"`
+	Value string `json:"value,omitempty"`
+}
+
+// LoadFixture reads a fixture file
+func LoadFixture(path string) (*Fixture, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	fixture := &Fixture{}
+	if err := json.Unmarshal(b, fixture); err != nil {
+		return nil, fmt.Errorf("hanatest: invalid fixture %s: %w", path, err)
+	}
+	return fixture, nil
+}
+
+// Save writes the fixture to path
+func (f *Fixture) Save(path string) error {
+	b, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(b, '\n'), 0644)
+}
+
+func encodeFixtureValue(v driver.Value) (Value, bool) {
+	switch v := v.(type) {
+	case nil:
+		return Value{Type: "null"}, true
+	case int64:
+		return Value{Type: "int64", Value: strconv.FormatInt(v, 10)}, true
+	case float64:
+		return Value{Type: "float64", Value: strconv.FormatFloat(v, 'g', -1, 64)}, true
+	case bool:
+		return Value{Type: "bool", Value: strconv.FormatBool(v)}, true
+	case string:
+		return Value{Type: "string", Value: v}, true
+	case []byte:
+		return Value{Type: "bytes", Value: base64.StdEncoding.EncodeToString(v)}, true
+	case time.Time:
+		return Value{Type: "time", Value: v.Format(time.RFC3339Nano)}, true
+	case *big.Rat:
+		return Value{Type: "decimal", Value: v.RatString()}, true
+	}
+	return Value{}, false
+}
+
+// encodeArg records an argument as passed to the driver
+func encodeArg(v interface{}) (Value, error) {
+	v, err := normalizeArg(v)
+	if err != nil {
+		return Value{}, err
+	}
+	if value, ok := encodeFixtureValue(v); ok {
+		return value, nil
+	}
+	return Value{Type: fmt.Sprintf("%T", v), Value: fmt.Sprint(v)}, nil
+}
+
+// normalizeArg converts an argument to a driver value where possible
+func normalizeArg(v interface{}) (interface{}, error) {
+	if valuer, ok := v.(driver.Valuer); ok {
+		var err error
+		if v, err = valuer.Value(); err != nil {
+			return nil, err
+		}
+	}
+	if driver.IsValue(v) {
+		return v, nil
+	}
+	if dv, err := driver.DefaultParameterConverter.ConvertValue(v); err == nil {
+		return dv, nil
+	}
+	return v, nil
+}
+
+func decodeFixtureValue(v Value) (driver.Value, error) {
+	var (
+		dv  driver.Value
+		err error
+	)
+	switch v.Type {
+	case "null":
+	case "int64":
+		dv, err = strconv.ParseInt(v.Value, 10, 64)
+	case "float64":
+		dv, err = strconv.ParseFloat(v.Value, 64)
+	case "bool":
+		dv, err = strconv.ParseBool(v.Value)
+	case "string":
+		dv = v.Value
+	case "bytes":
+		dv, err = base64.StdEncoding.DecodeString(v.Value)
+	case "time":
+		dv, err = time.Parse(time.RFC3339Nano, v.Value)
+	case "decimal":
+		r, ok := new(big.Rat).SetString(v.Value)
+		if !ok {
+			err = fmt.Errorf("invalid decimal %q", v.Value)
+		}
+		dv = r
+	default:
+		err = fmt.Errorf("unsupported value type %s", v.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("hanatest: %w", err)
+	}
+	return dv, nil
+}
+
+func encodeArgs(args []driver.NamedValue) ([]Value, error) {
+	var values []Value
+	for _, arg := range args {
+		value, err := encodeArg(arg.Value)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// Recorder is a driver.Connector recording the statements executed through
+// another connector, e.g. a go-hdb connector of a live database:
+//
+//	connector, _ := driver.NewDSNConnector(dsn)
+//	recorder := hanatest.NewRecorder(connector)
+//	db, err := gorm.Open(hdb.New(hdb.Config{Connector: recorder}), &gorm.Config{})
+//	...
+//	err = recorder.Fixture().Save("testdata/users.json")
+//
+// Arguments are recorded before the driver converts them to the parameter
+// types and result sets are read completely when a query is executed.
+type Recorder struct {
+	connector driver.Connector
+
+	mu      sync.Mutex
+	fixture Fixture
+}
+
+// NewRecorder returns a recorder of the statements executed through connector
+func NewRecorder(connector driver.Connector) *Recorder {
+	return &Recorder{connector: connector}
+}
+
+// Fixture returns a copy of the recorded statements
+func (r *Recorder) Fixture() *Fixture {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return &Fixture{Statements: append([]RecordedStatement(nil), r.fixture.Statements...)}
+}
+
+// Connect implements driver.Connector
+func (r *Recorder) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := r.connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &recordingConn{recorder: r, conn: conn}, nil
+}
+
+// Driver implements driver.Connector
+func (r *Recorder) Driver() driver.Driver {
+	return r.connector.Driver()
+}
+
+func (r *Recorder) record(stmt RecordedStatement) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fixture.Statements = append(r.fixture.Statements, stmt)
+}
+
+// recordExec records the result of an executed statement
+func (r *Recorder) recordExec(query string, args []Value, res driver.Result, err error) (driver.Result, error) {
+	if err == driver.ErrSkip {
+		return nil, err
+	}
+	stmt := RecordedStatement{Kind: KindExec, Query: query, Args: args}
+	if err != nil {
+		stmt.Error = err.Error()
+	} else if rowsAffected, err := res.RowsAffected(); err == nil {
+		stmt.RowsAffected = &rowsAffected
+	}
+	r.record(stmt)
+	return res, err
+}
+
+// recordQuery reads and records the result set of an executed query
+func (r *Recorder) recordQuery(query string, args []Value, rows driver.Rows, err error) (driver.Rows, error) {
+	if err == driver.ErrSkip {
+		return nil, err
+	}
+	stmt := RecordedStatement{Kind: KindQuery, Query: query, Args: args}
+	if err != nil {
+		stmt.Error = err.Error()
+		r.record(stmt)
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := &memoryRows{columns: rows.Columns()}
+	stmt.Columns = result.columns
+	dest := make([]driver.Value, len(result.columns))
+	for {
+		if err := rows.Next(dest); err == io.EOF {
+			break
+		} else if err != nil {
+			stmt.Error = err.Error()
+			r.record(stmt)
+			return nil, err
+		}
+
+		row, values := make([]driver.Value, len(dest)), make([]Value, len(dest))
+		for i, v := range dest {
+			if b, ok := v.([]byte); ok {
+				v = append([]byte{}, b...)
+			}
+			value, ok := encodeFixtureValue(v)
+			if !ok {
+				return nil, fmt.Errorf("hanatest: cannot record value of type %T of column %s", v, result.columns[i])
+			}
+			row[i], values[i] = v, value
+		}
+		result.rows = append(result.rows, row)
+		stmt.Rows = append(stmt.Rows, values)
+	}
+	r.record(stmt)
+	return result, nil
+}
+
+// recordingConn records the statements executed on a driver connection
+type recordingConn struct {
+	recorder *Recorder
+	conn     driver.Conn
+}
+
+func (c *recordingConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *recordingConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var (
+		stmt driver.Stmt
+		err  error
+	)
+	if preparer, ok := c.conn.(driver.ConnPrepareContext); ok {
+		stmt, err = preparer.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &recordingStmt{conn: c, stmt: stmt, query: query}, nil
+}
+
+func (c *recordingConn) Close() error { return c.conn.Close() }
+
+func (c *recordingConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *recordingConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	return c.conn.Begin() //nolint:staticcheck
+}
+
+func (c *recordingConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *recordingConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	var err error
+	nv.Value, err = driver.DefaultParameterConverter.ConvertValue(nv.Value)
+	return err
+}
+
+func (c *recordingConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	values, err := encodeArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	res, err := execer.ExecContext(ctx, query, args)
+	return c.recorder.recordExec(query, values, res, err)
+}
+
+func (c *recordingConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	values, err := encodeArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := queryer.QueryContext(ctx, query, args)
+	return c.recorder.recordQuery(query, values, rows, err)
+}
+
+// recordingStmt records the executions of a prepared statement
+type recordingStmt struct {
+	conn  *recordingConn
+	stmt  driver.Stmt
+	query string
+	// args are the arguments before their conversion by the driver
+	args []Value
+}
+
+func (s *recordingStmt) Close() error  { return s.stmt.Close() }
+func (s *recordingStmt) NumInput() int { return s.stmt.NumInput() }
+
+func (s *recordingStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	value, err := encodeArg(nv.Value)
+	if err != nil {
+		return err
+	}
+	if nv.Ordinal == 1 {
+		s.args = nil
+	}
+	s.args = append(s.args, value)
+
+	if checker, ok := s.stmt.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return s.conn.CheckNamedValue(nv)
+}
+
+// takeArgs returns the recorded arguments of the current execution
+func (s *recordingStmt) takeArgs(args []driver.NamedValue) []Value {
+	values := s.args
+	s.args = nil
+	if len(values) != len(args) {
+		// the arguments were not checked, e.g. by drivers removing arguments
+		values, _ = encodeArgs(args)
+	}
+	return values
+}
+
+func (s *recordingStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, errors.New("hanatest: recording statements require ExecContext")
+}
+
+func (s *recordingStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return nil, errors.New("hanatest: recording statements require QueryContext")
+}
+
+func (s *recordingStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := s.stmt.(driver.StmtExecContext)
+	if !ok {
+		return nil, errors.New("hanatest: statement does not support ExecContext")
+	}
+	values := s.takeArgs(args)
+	res, err := execer.ExecContext(ctx, args)
+	return s.conn.recorder.recordExec(s.query, values, res, err)
+}
+
+func (s *recordingStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := s.stmt.(driver.StmtQueryContext)
+	if !ok {
+		return nil, errors.New("hanatest: statement does not support QueryContext")
+	}
+	values := s.takeArgs(args)
+	rows, err := queryer.QueryContext(ctx, args)
+	return s.conn.recorder.recordQuery(s.query, values, rows, err)
+}
+
+// Replayer is a driver.Connector serving the statements of a fixture in their
+// recorded order, unexpected statements or arguments fail:
+//
+//	replayer, err := hanatest.NewReplayer("testdata/users.json")
+//	db, err := gorm.Open(hdb.New(hdb.Config{Connector: replayer}), &gorm.Config{})
+//	...
+//	err = replayer.ExpectationsWereMet()
+//
+// Transactions are accepted without being replayed and recorded errors are
+// returned as plain errors with the recorded text.
+type Replayer struct {
+	mu      sync.Mutex
+	fixture *Fixture
+	next    int
+	errs    []error
+}
+
+// NewReplayer returns a replayer of the fixture file at path
+func NewReplayer(path string) (*Replayer, error) {
+	fixture, err := LoadFixture(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewFixtureReplayer(fixture), nil
+}
+
+// NewFixtureReplayer returns a replayer of fixture
+func NewFixtureReplayer(fixture *Fixture) *Replayer {
+	return &Replayer{fixture: fixture}
+}
+
+// Connect implements driver.Connector
+func (r *Replayer) Connect(ctx context.Context) (driver.Conn, error) {
+	return &replayConn{replayer: r}, nil
+}
+
+// Driver implements driver.Connector
+func (r *Replayer) Driver() driver.Driver {
+	return replayDriver{replayer: r}
+}
+
+// ExpectationsWereMet reports unexpected statements and recorded statements not replayed
+func (r *Replayer) ExpectationsWereMet() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.errs) != 0 {
+		return r.errs[0]
+	}
+	if r.next < len(r.fixture.Statements) {
+		return fmt.Errorf("hanatest: recorded statement %q was not replayed", r.fixture.Statements[r.next].Query)
+	}
+	return nil
+}
+
+// replay returns the next recorded statement if it matches the executed one
+func (r *Replayer) replay(kind, query string, args []driver.NamedValue) (*RecordedStatement, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	fail := func(err error) (*RecordedStatement, error) {
+		r.errs = append(r.errs, err)
+		return nil, err
+	}
+	if r.next >= len(r.fixture.Statements) {
+		return fail(fmt.Errorf("hanatest: unexpected statement %q", query))
+	}
+	stmt := &r.fixture.Statements[r.next]
+	if stmt.Kind != kind || normalizeQuery(stmt.Query) != normalizeQuery(query) {
+		return fail(fmt.Errorf("hanatest: %s %q does not match recorded %s %q", kind, query, stmt.Kind, stmt.Query))
+	}
+	values, err := encodeArgs(args)
+	if err != nil {
+		return fail(err)
+	}
+	if len(values) != len(stmt.Args) {
+		return fail(fmt.Errorf("hanatest: statement %q: expected %d arguments, got %d", query, len(stmt.Args), len(values)))
+	}
+	for i, v := range values {
+		if v != stmt.Args[i] {
+			return fail(fmt.Errorf("hanatest: statement %q: argument %d: expected %s %s, got %s %s",
+				query, i+1, stmt.Args[i].Type, stmt.Args[i].Value, v.Type, v.Value))
+		}
+	}
+	r.next++
+
+	if stmt.Error != "" {
+		return nil, errors.New(stmt.Error)
+	}
+	return stmt, nil
+}
+
+type replayDriver struct {
+	replayer *Replayer
+}
+
+func (d replayDriver) Open(name string) (driver.Conn, error) {
+	return &replayConn{replayer: d.replayer}, nil
+}
+
+// replayConn is a connection to a replayer
+type replayConn struct {
+	replayer *Replayer
+}
+
+func (c *replayConn) Prepare(query string) (driver.Stmt, error) {
+	return &replayStmt{conn: c, query: query}, nil
+}
+
+func (c *replayConn) Close() error              { return nil }
+func (c *replayConn) Begin() (driver.Tx, error) { return c, nil }
+func (c *replayConn) Commit() error             { return nil }
+func (c *replayConn) Rollback() error           { return nil }
+
+// CheckNamedValue accepts all arguments, they are compared with the recorded ones
+func (c *replayConn) CheckNamedValue(nv *driver.NamedValue) error {
+	return nil
+}
+
+func (c *replayConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	stmt, err := c.replayer.replay(KindExec, query, args)
+	if err != nil {
+		return nil, err
+	}
+	if stmt.RowsAffected == nil {
+		return driver.ResultNoRows, nil
+	}
+	return driver.RowsAffected(*stmt.RowsAffected), nil
+}
+
+func (c *replayConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	stmt, err := c.replayer.replay(KindQuery, query, args)
+	if err != nil {
+		return nil, err
+	}
+	rows := &memoryRows{columns: stmt.Columns}
+	for _, values := range stmt.Rows {
+		row := make([]driver.Value, len(values))
+		for i, v := range values {
+			if row[i], err = decodeFixtureValue(v); err != nil {
+				return nil, err
+			}
+		}
+		rows.rows = append(rows.rows, row)
+	}
+	return rows, nil
+}
+
+// replayStmt is a statement prepared on a replay connection
+type replayStmt struct {
+	conn  *replayConn
+	query string
+}
+
+func (s *replayStmt) Close() error  { return nil }
+func (s *replayStmt) NumInput() int { return -1 }
+
+func (s *replayStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, errors.New("hanatest: replay statements require ExecContext")
+}
+
+func (s *replayStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return nil, errors.New("hanatest: replay statements require QueryContext")
+}
+
+func (s *replayStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return s.conn.ExecContext(ctx, s.query, args)
+}
+
+func (s *replayStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	return s.conn.QueryContext(ctx, s.query, args)
+}
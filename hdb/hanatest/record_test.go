@@ -0,0 +1,117 @@
+package hanatest_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/SAP/go-hdb/driver"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	hdb "gorm.io/driver/hana/hdb"
+	"gorm.io/driver/hana/hdb/hanatest"
+)
+
+func recordAccounts(t *testing.T, path string) {
+	createdAt := time.Date(2022, 3, 14, 15, 9, 26, 0, time.UTC)
+
+	script := hanatest.NewScript()
+	script.Expect(`UPDATE "accounts" SET "active"=? WHERE name = ?`).
+		WithArgs(true, "jinzhu").
+		ReturnsRowsAffected(2)
+	script.Expect(`SELECT * FROM "accounts" WHERE name = ?`).
+		WithArgs("jinzhu").
+		ReturnsColumns(
+			hanatest.Column{Name: "id", Type: hanatest.BigInt},
+			hanatest.Column{Name: "name", Type: hanatest.NVarchar, Length: 256},
+			hanatest.Column{Name: "active", Type: hanatest.Boolean},
+			hanatest.Column{Name: "score", Type: hanatest.Double, Nullable: true},
+			hanatest.Column{Name: "balance", Type: hanatest.Decimal, Length: 38, Scale: 2},
+			hanatest.Column{Name: "created_at", Type: hanatest.Timestamp, Nullable: true},
+		).
+		ReturnsRow(1, "jinzhu", true, 1.5, "1234.56", createdAt).
+		ReturnsRow(2, "jinzhu", true, nil, "-0.5", nil)
+
+	srv := hanatest.NewServer(script)
+	defer srv.Close()
+
+	connector, err := driver.NewDSNConnector(srv.DSN)
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	recorder := hanatest.NewRecorder(connector)
+	db, err := gorm.Open(hdb.New(hdb.Config{Connector: recorder}), &gorm.Config{Logger: logger.Discard})
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	defer func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	}()
+
+	checkAccounts(t, db)
+	assert.Nil(t, script.ExpectationsWereMet())
+	assert.Nil(t, recorder.Fixture().Save(path))
+}
+
+func checkAccounts(t *testing.T, db *gorm.DB) {
+	result := db.Model(&account{}).Where("name = ?", "jinzhu").Update("active", true)
+	assert.Nil(t, result.Error)
+	assert.Equal(t, int64(2), result.RowsAffected)
+
+	var accounts []account
+	assert.Nil(t, db.Where("name = ?", "jinzhu").Find(&accounts).Error)
+	if assert.Len(t, accounts, 2) {
+		assert.Equal(t, uint(1), accounts[0].ID)
+		assert.Equal(t, 1.5, accounts[0].Score)
+		assert.Equal(t, "1234.56", accounts[0].Balance.String())
+		assert.True(t, time.Date(2022, 3, 14, 15, 9, 26, 0, time.UTC).Equal(accounts[0].CreatedAt))
+		assert.Equal(t, "-0.5", accounts[1].Balance.String())
+		assert.True(t, accounts[1].CreatedAt.IsZero())
+	}
+}
+
+func openReplayer(t *testing.T, path string) (*gorm.DB, *hanatest.Replayer) {
+	replayer, err := hanatest.NewReplayer(path)
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	db, err := gorm.Open(hdb.New(hdb.Config{Connector: replayer}), &gorm.Config{Logger: logger.Discard})
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	return db, replayer
+}
+
+func TestRecordReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "accounts.json")
+	recordAccounts(t, path)
+
+	fixture, err := hanatest.LoadFixture(path)
+	if assert.Nil(t, err) && assert.Len(t, fixture.Statements, 2) {
+		assert.Equal(t, hanatest.KindExec, fixture.Statements[0].Kind)
+		assert.Equal(t, []hanatest.Value{{Type: "bool", Value: "true"}, {Type: "string", Value: "jinzhu"}}, fixture.Statements[0].Args)
+		assert.Equal(t, hanatest.KindQuery, fixture.Statements[1].Kind)
+		assert.Len(t, fixture.Statements[1].Rows, 2)
+	}
+
+	db, replayer := openReplayer(t, path)
+	checkAccounts(t, db)
+	assert.Nil(t, replayer.ExpectationsWereMet())
+}
+
+func TestReplayUnexpectedStatement(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "accounts.json")
+	recordAccounts(t, path)
+
+	db, replayer := openReplayer(t, path)
+	assert.NotNil(t, db.Model(&account{}).Where("name = ?", "jinzhu").Update("active", false).Error)
+	assert.NotNil(t, replayer.ExpectationsWereMet())
+
+	db, replayer = openReplayer(t, path)
+	assert.NotNil(t, db.Delete(&account{}, 1).Error)
+	assert.NotNil(t, replayer.ExpectationsWereMet())
+}
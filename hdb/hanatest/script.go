@@ -0,0 +1,335 @@
+package hanatest
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"fmt"
+	"math/big"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// IdentityQuery selects the identity value generated by the last insert of a session
+const IdentityQuery = "SELECT CURRENT_IDENTITY_VALUE() FROM DUMMY"
+
+// Script is a handler answering an ordered list of expected statements
+type Script struct {
+	mu           sync.Mutex
+	expectations []*Expectation
+	next         int
+	errs         []error
+}
+
+// NewScript returns a script without expectations
+func NewScript() *Script {
+	return &Script{}
+}
+
+// Expectation is a statement expected by a script and its result
+type Expectation struct {
+	query        string
+	pattern      *regexp.Regexp
+	args         []interface{}
+	params       []Type
+	columns      []Column
+	rows         [][]interface{}
+	rowsAffected int64
+	err          error
+}
+
+// Expect expects query, whitespace differences are ignored
+func (s *Script) Expect(query string) *Expectation {
+	return s.add(&Expectation{query: normalizeQuery(query)})
+}
+
+// ExpectRegexp expects a statement matching pattern
+func (s *Script) ExpectRegexp(pattern string) *Expectation {
+	return s.add(&Expectation{pattern: regexp.MustCompile(pattern)})
+}
+
+// ExpectIdentity expects IdentityQuery returning id
+func (s *Script) ExpectIdentity(id int64) *Expectation {
+	return s.Expect(IdentityQuery).ReturnsColumns(Column{Name: "CURRENT_IDENTITY_VALUE()", Type: BigInt}).ReturnsRow(id)
+}
+
+func (s *Script) add(e *Expectation) *Expectation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expectations = append(s.expectations, e)
+	return e
+}
+
+// WithArgs expects the arguments of the statement, the parameter types are
+// derived from the arguments unless declared by WithParams
+func (e *Expectation) WithArgs(args ...interface{}) *Expectation {
+	e.args = args
+	return e
+}
+
+// WithParams declares the parameter types of the statement
+func (e *Expectation) WithParams(params ...Type) *Expectation {
+	e.params = params
+	return e
+}
+
+// ReturnsColumns declares the statement as query with columns
+func (e *Expectation) ReturnsColumns(columns ...Column) *Expectation {
+	e.columns = columns
+	return e
+}
+
+// ReturnsRow adds a row to the result of a query
+func (e *Expectation) ReturnsRow(values ...interface{}) *Expectation {
+	e.rows = append(e.rows, values)
+	return e
+}
+
+// ReturnsRowsAffected sets the number of affected rows of the statement
+func (e *Expectation) ReturnsRowsAffected(rowsAffected int64) *Expectation {
+	e.rowsAffected = rowsAffected
+	return e
+}
+
+// ReturnsError fails the execution of the statement with err, use *Error for SQL errors
+func (e *Expectation) ReturnsError(err error) *Expectation {
+	e.err = err
+	return e
+}
+
+func (e *Expectation) String() string {
+	if e.pattern != nil {
+		return e.pattern.String()
+	}
+	return e.query
+}
+
+func (e *Expectation) match(query string) bool {
+	if e.pattern != nil {
+		return e.pattern.MatchString(query)
+	}
+	return e.query == normalizeQuery(query)
+}
+
+// paramTypes returns the declared parameter types, the types of the expected
+// arguments or NVARCHAR for each parameter marker of query
+func (e *Expectation) paramTypes(query string) ([]Type, error) {
+	if e.params != nil {
+		return e.params, nil
+	}
+	if e.args == nil {
+		params := make([]Type, countParams(query))
+		for i := range params {
+			params[i] = NVarchar
+		}
+		return params, nil
+	}
+
+	params := make([]Type, len(e.args))
+	for i, arg := range e.args {
+		t, err := typeOf(arg)
+		if err != nil {
+			return nil, err
+		}
+		params[i] = t
+	}
+	return params, nil
+}
+
+// Prepare implements Handler
+func (s *Script) Prepare(query string) (*Statement, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.next >= len(s.expectations) {
+		return nil, s.fail(fmt.Errorf("hanatest: unexpected statement %q", query))
+	}
+	e := s.expectations[s.next]
+	if !e.match(query) {
+		return nil, s.fail(fmt.Errorf("hanatest: statement %q does not match expected %q", normalizeQuery(query), e))
+	}
+	params, err := e.paramTypes(query)
+	if err != nil {
+		return nil, s.fail(err)
+	}
+	return &Statement{Query: query, Params: params, Columns: e.columns, expectation: e}, nil
+}
+
+// Execute implements Handler
+func (s *Script) Execute(stmt *Statement, args [][]interface{}) (*Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.next >= len(s.expectations) || s.expectations[s.next] != stmt.expectation {
+		return nil, s.fail(fmt.Errorf("hanatest: statement %q executed out of order", stmt.Query))
+	}
+	e := stmt.expectation
+	s.next++
+
+	if e.args != nil {
+		var actual []interface{}
+		for _, row := range args {
+			actual = append(actual, row...)
+		}
+		if err := matchArgs(e.args, actual); err != nil {
+			return nil, s.fail(fmt.Errorf("hanatest: statement %q: %w", stmt.Query, err))
+		}
+	}
+	if e.err != nil {
+		return nil, e.err
+	}
+	return &Result{Rows: e.rows, RowsAffected: e.rowsAffected}, nil
+}
+
+func (s *Script) fail(err error) error {
+	s.errs = append(s.errs, err)
+	return err
+}
+
+// ExpectationsWereMet reports unexpected statements and expectations not executed
+func (s *Script) ExpectationsWereMet() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.errs) != 0 {
+		return s.errs[0]
+	}
+	if s.next < len(s.expectations) {
+		return fmt.Errorf("hanatest: expected statement %q was not executed", s.expectations[s.next])
+	}
+	return nil
+}
+
+// Argument matches an argument of a statement
+type Argument interface {
+	Match(v interface{}) bool
+}
+
+type anyArgument Type
+
+func (a anyArgument) Match(v interface{}) bool { return true }
+
+// Any matches any argument of a parameter of type t
+func Any(t Type) Argument {
+	return anyArgument(t)
+}
+
+var whitespace = regexp.MustCompile(`\s+`)
+
+func normalizeQuery(query string) string {
+	return strings.TrimSpace(whitespace.ReplaceAllString(query, " "))
+}
+
+// countParams counts the parameter markers of query outside of quotes
+func countParams(query string) int {
+	var quote rune
+	cnt := 0
+	for _, r := range query {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == '?':
+			cnt++
+		}
+	}
+	return cnt
+}
+
+func value(v interface{}) (interface{}, error) {
+	if valuer, ok := v.(driver.Valuer); ok {
+		return valuer.Value()
+	}
+	return v, nil
+}
+
+// typeOf returns the parameter type of an expected argument
+func typeOf(arg interface{}) (Type, error) {
+	if a, ok := arg.(anyArgument); ok {
+		return Type(a), nil
+	}
+	if _, ok := arg.(Argument); ok {
+		return NVarchar, nil
+	}
+	v, err := value(arg)
+	if err != nil {
+		return 0, err
+	}
+
+	switch v.(type) {
+	case nil, string:
+		return NVarchar, nil
+	case []byte:
+		return VarBinary, nil
+	case bool:
+		return Boolean, nil
+	case time.Time:
+		return Timestamp, nil
+	case *big.Rat:
+		return Decimal, nil
+	}
+	switch reflect.ValueOf(v).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return BigInt, nil
+	case reflect.Float32, reflect.Float64:
+		return Double, nil
+	case reflect.String:
+		return NVarchar, nil
+	}
+	return 0, fmt.Errorf("hanatest: no parameter type for argument %T", arg)
+}
+
+func matchArgs(expected, actual []interface{}) error {
+	if len(expected) != len(actual) {
+		return fmt.Errorf("expected %d arguments, got %d", len(expected), len(actual))
+	}
+	for i, arg := range expected {
+		if matcher, ok := arg.(Argument); ok {
+			if !matcher.Match(actual[i]) {
+				return fmt.Errorf("argument %d: %v does not match", i+1, actual[i])
+			}
+			continue
+		}
+		v, err := value(arg)
+		if err != nil {
+			return err
+		}
+		if !equal(v, actual[i]) {
+			return fmt.Errorf("argument %d: expected %v, got %v", i+1, v, actual[i])
+		}
+	}
+	return nil
+}
+
+// equal compares an expected value with a decoded parameter value
+func equal(expected, actual interface{}) bool {
+	if expected == nil || actual == nil {
+		return expected == nil && actual == nil
+	}
+	switch actual := actual.(type) {
+	case int64:
+		i, err := asInt64(expected)
+		return err == nil && i == actual
+	case float64:
+		f, err := asFloat64(expected)
+		return err == nil && f == actual
+	case *big.Rat:
+		r, err := asRat(expected)
+		return err == nil && r.Cmp(actual) == 0
+	case time.Time:
+		t, ok := expected.(time.Time)
+		return ok && t.Equal(actual)
+	case []byte:
+		return bytes.Equal(asBytes(expected), actual)
+	case string:
+		s, ok := expected.(string)
+		return ok && s == actual
+	}
+	return reflect.DeepEqual(expected, actual)
+}
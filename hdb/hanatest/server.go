@@ -0,0 +1,613 @@
+// Package hanatest provides an in-process fake SAP HANA server for tests.
+//
+// The server speaks the HANA SQL command network protocol as far as the go-hdb
+// driver needs it to connect, prepare and execute statements, so gorm can be
+// tested end to end without a database:
+//
+//	script := hanatest.NewScript()
+//	script.Expect(`SELECT * FROM "users"`).
+//		ReturnsColumns(hanatest.Column{Name: "id", Type: hanatest.BigInt}).
+//		ReturnsRow(1)
+//
+//	srv := hanatest.NewServer(script)
+//	defer srv.Close()
+//
+//	db, err := gorm.Open(hdb.Open(srv.DSN), &gorm.Config{})
+//
+// Result sets are sent in a single packet and LOB columns and parameters are
+// not supported.
+package hanatest
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Default credentials accepted by the server
+const (
+	DefaultUsername = "SYSTEM"
+	DefaultPassword = "Manager1"
+)
+
+// FullVersionString is the version of the server reported to the client
+const FullVersionString = "2.00.059.00.1636466434"
+
+// Handler prepares and executes the statements sent to the server
+type Handler interface {
+	// Prepare describes the parameters and result columns of query
+	Prepare(query string) (*Statement, error)
+	// Execute executes a prepared statement with one row of arguments per
+	// execution, args is empty for statements without parameters
+	Execute(stmt *Statement, args [][]interface{}) (*Result, error)
+}
+
+// Statement is a prepared statement
+type Statement struct {
+	Query   string
+	Params  []Type
+	Columns []Column
+
+	// expectation is the script expectation the statement was prepared for
+	expectation *Expectation
+	// builtin is the result of statements answered by the server itself
+	builtin *Result
+}
+
+// Result is the result of an executed statement
+type Result struct {
+	// Rows are the rows of queries, one value per column
+	Rows         [][]interface{}
+	RowsAffected int64
+}
+
+// Error is an SQL error returned to the client, other errors returned by
+// handlers are sent as general errors
+type Error struct {
+	Code     int
+	Position int
+	SQLState string
+	Text     string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("SQL Error %d - %s", e.Code, e.Text)
+}
+
+const (
+	errCodeGeneral        = 2
+	errCodeNotSupported   = 7
+	errCodeAuthentication = 10
+)
+
+// Server is a fake HANA server listening on a local address
+type Server struct {
+	// DSN connects the go-hdb driver to the started server
+	DSN      string
+	Handler  Handler
+	Username string
+	Password string
+	Listener net.Listener
+
+	sessionID int64
+	wg        sync.WaitGroup
+	mu        sync.Mutex
+	conns     map[net.Conn]struct{}
+	closed    bool
+}
+
+// NewServer starts a server with handler
+func NewServer(handler Handler) *Server {
+	srv := NewUnstartedServer(handler)
+	srv.Start()
+	return srv
+}
+
+// NewUnstartedServer returns a server with handler listening on a local port,
+// credentials can be changed before the server is started
+func NewUnstartedServer(handler Handler) *Server {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(fmt.Sprintf("hanatest: failed to listen on a port: %v", err))
+	}
+	return &Server{
+		Handler:  handler,
+		Username: DefaultUsername,
+		Password: DefaultPassword,
+		Listener: l,
+		conns:    map[net.Conn]struct{}{},
+	}
+}
+
+// Start starts accepting connections
+func (srv *Server) Start() {
+	u := url.URL{
+		Scheme: "hdb",
+		User:   url.UserPassword(srv.Username, srv.Password),
+		Host:   srv.Listener.Addr().String(),
+	}
+	srv.DSN = u.String()
+
+	srv.wg.Add(1)
+	go srv.serve()
+}
+
+// Close stops the server and closes all connections
+func (srv *Server) Close() {
+	srv.mu.Lock()
+	srv.closed = true
+	srv.Listener.Close()
+	for conn := range srv.conns {
+		conn.Close()
+	}
+	srv.mu.Unlock()
+	srv.wg.Wait()
+}
+
+func (srv *Server) serve() {
+	defer srv.wg.Done()
+	for {
+		conn, err := srv.Listener.Accept()
+		if err != nil {
+			return
+		}
+
+		srv.mu.Lock()
+		if srv.closed {
+			srv.mu.Unlock()
+			conn.Close()
+			return
+		}
+		srv.conns[conn] = struct{}{}
+		srv.wg.Add(1)
+		srv.mu.Unlock()
+
+		go func() {
+			defer srv.wg.Done()
+			s := &session{srv: srv, conn: conn, rd: bufio.NewReader(conn), stmts: map[uint64]*Statement{}}
+			s.run()
+
+			srv.mu.Lock()
+			delete(srv.conns, conn)
+			srv.mu.Unlock()
+			conn.Close()
+		}()
+	}
+}
+
+// session serves a client connection
+type session struct {
+	srv   *Server
+	conn  net.Conn
+	rd    *bufio.Reader
+	id    int64
+	dfv   int
+	stmts map[uint64]*Statement
+
+	lastStmtID      uint64
+	lastResultsetID uint64
+}
+
+func (s *session) run() {
+	if err := s.handshake(); err != nil {
+		return
+	}
+	if err := s.authenticate(); err != nil {
+		return
+	}
+
+	for {
+		req, err := readRequest(s.rd)
+		if err != nil {
+			return
+		}
+
+		var r *reply
+		switch req.messageType {
+		case mtDisconnect:
+			return
+		case mtCommit, mtRollback, mtCloseResultset:
+			r = &reply{}
+		case mtDropStatementID:
+			if p, ok := req.part(pkStatementID); ok {
+				delete(s.stmts, (&decoder{b: p.data}).uint64())
+			}
+			r = &reply{}
+		case mtExecuteDirect:
+			r, err = s.executeDirect(req)
+		case mtPrepare:
+			r, err = s.prepare(req)
+		case mtExecute:
+			r, err = s.execute(req)
+		default:
+			err = &Error{Code: errCodeNotSupported, Text: fmt.Sprintf("feature not supported: message type %d", req.messageType)}
+		}
+
+		if err != nil {
+			r = errorReply(err)
+		}
+		if err := writeReply(s.conn, s.id, r); err != nil {
+			return
+		}
+	}
+}
+
+func (s *session) handshake() error {
+	if _, err := io.ReadFull(s.rd, make([]byte, initRequestSize)); err != nil {
+		return err
+	}
+	e := &encoder{}
+	e.int8(4)   // product version major
+	e.int16(20) // product version minor
+	e.int8(4)   // protocol version major
+	e.int16(1)  // protocol version minor
+	e.zeroes(2)
+	_, err := s.conn.Write(e.b)
+	return err
+}
+
+const (
+	methodSCRAMSHA256   = "SCRAMSHA256"
+	saltSize            = 16
+	serverChallengeSize = 48
+)
+
+// authenticate runs the SCRAMSHA256 authentication of the client
+func (s *session) authenticate() error {
+	req, err := readRequest(s.rd)
+	if err != nil {
+		return err
+	}
+	p, ok := req.part(pkAuthentication)
+	if req.messageType != mtAuthenticate || !ok {
+		return s.authenticationFailed()
+	}
+	d := &decoder{b: p.data}
+	numMethods := (int(d.int16()) - 1) / 2
+	username := decodeCESU8(d.shortBytes())
+	var clientChallenge []byte
+	for i := 0; i < numMethods; i++ {
+		method, challenge := string(d.shortBytes()), d.shortBytes()
+		if method == methodSCRAMSHA256 {
+			clientChallenge = append([]byte{}, challenge...)
+		}
+	}
+	if d.err != nil || clientChallenge == nil {
+		return s.authenticationFailed()
+	}
+
+	salt, serverChallenge := make([]byte, saltSize), make([]byte, serverChallengeSize)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	if _, err := rand.Read(serverChallenge); err != nil {
+		return err
+	}
+
+	prms := &encoder{}
+	prms.int16(2)
+	prms.shortBytes(salt)
+	prms.shortBytes(serverChallenge)
+	e := &encoder{}
+	e.int16(2)
+	e.shortBytes([]byte(methodSCRAMSHA256))
+	e.shortBytes(prms.b)
+	r := &reply{}
+	r.add(pkAuthentication, 0, 1, e.b)
+	if err := writeReply(s.conn, 0, r); err != nil {
+		return err
+	}
+
+	if req, err = readRequest(s.rd); err != nil {
+		return err
+	}
+	if p, ok = req.part(pkAuthentication); req.messageType != mtConnect || !ok {
+		return s.authenticationFailed()
+	}
+	d = &decoder{b: p.data}
+	d.int16() // number of parameters
+	d.shortBytes()
+	d.shortBytes()
+	d.byte() // length of sub parameters
+	d.int16()
+	proof := d.shortBytes()
+	if d.err != nil || username != s.srv.Username || !hmac.Equal(proof, clientProof(s.srv.Password, salt, serverChallenge, clientChallenge)) {
+		return s.authenticationFailed()
+	}
+
+	s.dfv = 1
+	if p, ok := req.part(pkConnectOptions); ok {
+		options, err := decodeOptions(p)
+		if err != nil {
+			return err
+		}
+		if dfv, ok := options[coDataFormatVersion2].(int32); ok {
+			s.dfv = int(dfv)
+		}
+	}
+	s.id = atomic.AddInt64(&s.srv.sessionID, 1)
+
+	e = &encoder{}
+	e.int16(2)
+	e.shortBytes([]byte(methodSCRAMSHA256))
+	e.byte(0) // no server proof
+	options := map[int8]interface{}{
+		coDataFormatVersion2: int32(s.dfv),
+		coFullVersionString:  FullVersionString,
+	}
+	r = &reply{}
+	r.add(pkAuthentication, 0, 1, e.b)
+	r.add(pkConnectOptions, 0, len(options), encodeOptions([]int8{coDataFormatVersion2, coFullVersionString}, options))
+	return writeReply(s.conn, s.id, r)
+}
+
+func (s *session) authenticationFailed() error {
+	writeReply(s.conn, 0, errorReply(&Error{Code: errCodeAuthentication, SQLState: "28000", Text: "authentication failed"}))
+	return errors.New("hanatest: authentication failed")
+}
+
+// clientProof computes the SCRAMSHA256 proof expected from the client
+func clientProof(password string, salt, serverChallenge, clientChallenge []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(password))
+	mac.Write(salt)
+	key := sha256.Sum256(mac.Sum(nil))
+
+	storedKey := sha256.Sum256(key[:])
+	mac = hmac.New(sha256.New, storedKey[:])
+	mac.Write(salt)
+	mac.Write(serverChallenge)
+	mac.Write(clientChallenge)
+	proof := mac.Sum(nil)
+	for i := range proof {
+		proof[i] ^= key[i]
+	}
+	return proof
+}
+
+// builtinStatement answers the statements the driver sends on its own
+func builtinStatement(query string) *Statement {
+	switch q := strings.ToLower(normalizeQuery(query)); {
+	case q == "select 1 from dummy":
+		return &Statement{
+			Query:   query,
+			Columns: []Column{{Name: "1", Type: Integer}},
+			builtin: &Result{Rows: [][]interface{}{{1}}},
+		}
+	case strings.HasPrefix(q, "set transaction "), strings.HasPrefix(q, "set schema "):
+		return &Statement{Query: query, builtin: &Result{}}
+	}
+	return nil
+}
+
+func (s *session) statement(query string) (*Statement, error) {
+	if stmt := builtinStatement(query); stmt != nil {
+		return stmt, nil
+	}
+	stmt, err := s.srv.Handler.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range stmt.Params {
+		if _, ok := typeNames[t]; !ok {
+			return nil, fmt.Errorf("hanatest: unsupported parameter type %s", t)
+		}
+	}
+	return stmt, nil
+}
+
+func (s *session) exec(stmt *Statement, args [][]interface{}) (*Result, error) {
+	if stmt.builtin != nil {
+		return stmt.builtin, nil
+	}
+	return s.srv.Handler.Execute(stmt, args)
+}
+
+func (s *session) executeDirect(req *request) (*reply, error) {
+	query, err := req.command()
+	if err != nil {
+		return nil, err
+	}
+	stmt, err := s.statement(query)
+	if err != nil {
+		return nil, err
+	}
+	if len(stmt.Params) != 0 {
+		return nil, fmt.Errorf("hanatest: statement %q executed without its %d parameters", query, len(stmt.Params))
+	}
+	res, err := s.exec(stmt, nil)
+	if err != nil {
+		return nil, err
+	}
+	return s.result(stmt, res, true)
+}
+
+func (s *session) prepare(req *request) (*reply, error) {
+	query, err := req.command()
+	if err != nil {
+		return nil, err
+	}
+	stmt, err := s.statement(query)
+	if err != nil {
+		return nil, err
+	}
+	s.lastStmtID++
+	s.stmts[s.lastStmtID] = stmt
+
+	e := &encoder{}
+	e.uint64(s.lastStmtID)
+	r := &reply{functionCode: functionCodeOf(query)}
+	r.add(pkStatementID, 0, 1, e.b)
+	if len(stmt.Columns) != 0 {
+		r.add(pkResultMetadata, 0, len(stmt.Columns), encodeResultMetadata(stmt.Columns))
+	}
+	if len(stmt.Params) != 0 {
+		r.add(pkParameterMetadata, 0, len(stmt.Params), encodeParameterMetadata(stmt.Params))
+	}
+	return r, nil
+}
+
+func (s *session) execute(req *request) (*reply, error) {
+	p, ok := req.part(pkStatementID)
+	if !ok {
+		return nil, errors.New("hanatest: execute without statement id")
+	}
+	stmt, ok := s.stmts[(&decoder{b: p.data}).uint64()]
+	if !ok {
+		return nil, errors.New("hanatest: invalid statement id")
+	}
+
+	var args [][]interface{}
+	if p, ok := req.part(pkParameters); ok && len(stmt.Params) != 0 {
+		d := &decoder{b: p.data}
+		for i := 0; i < p.argCount; i++ {
+			row := make([]interface{}, len(stmt.Params))
+			for j, t := range stmt.Params {
+				v, err := decodeParam(d, t)
+				if err != nil {
+					return nil, err
+				}
+				row[j] = v
+			}
+			args = append(args, row)
+		}
+	}
+
+	res, err := s.exec(stmt, args)
+	if err != nil {
+		return nil, err
+	}
+	return s.result(stmt, res, false)
+}
+
+// result replies the result set of queries or the affected rows of other statements
+func (s *session) result(stmt *Statement, res *Result, direct bool) (*reply, error) {
+	if res == nil {
+		res = &Result{}
+	}
+	fc := functionCodeOf(stmt.Query)
+	r := &reply{functionCode: fc}
+
+	if len(stmt.Columns) == 0 {
+		if fc != fcDDL {
+			e := &encoder{}
+			e.int32(int32(res.RowsAffected))
+			r.add(pkRowsAffected, 0, 1, e.b)
+		}
+		return r, nil
+	}
+
+	e := &encoder{}
+	for _, row := range res.Rows {
+		if len(row) != len(stmt.Columns) {
+			return nil, fmt.Errorf("hanatest: row with %d values for %d columns", len(row), len(stmt.Columns))
+		}
+		for i, v := range row {
+			if err := encodeValue(e, stmt.Columns[i].Type, v, s.dfv); err != nil {
+				return nil, fmt.Errorf("hanatest: column %s: %w", stmt.Columns[i].Name, err)
+			}
+		}
+	}
+
+	if direct {
+		r.add(pkResultMetadata, 0, len(stmt.Columns), encodeResultMetadata(stmt.Columns))
+	}
+	s.lastResultsetID++
+	id := &encoder{}
+	id.uint64(s.lastResultsetID)
+	r.add(pkResultsetID, 0, 1, id.b)
+	r.add(pkResultset, paLastPacket|paResultsetClosed, len(res.Rows), e.b)
+	return r, nil
+}
+
+func errorReply(err error) *reply {
+	var sqlErr *Error
+	if !errors.As(err, &sqlErr) {
+		sqlErr = &Error{Code: errCodeGeneral, Text: err.Error()}
+	}
+	sqlState := sqlErr.SQLState
+	if sqlState == "" {
+		sqlState = "HY000"
+	}
+
+	e := &encoder{}
+	e.int32(int32(sqlErr.Code))
+	e.int32(int32(sqlErr.Position))
+	e.int32(int32(len(sqlErr.Text)))
+	e.int8(1) // error level
+	e.bytes([]byte(fmt.Sprintf("%-5.5s", sqlState)))
+	e.bytes([]byte(sqlErr.Text))
+	e.byte(0) // single errors are one byte longer than their text
+	return &reply{kind: skError, parts: []part{{kind: pkError, argCount: 1, data: e.b}}}
+}
+
+// functionCodeOf derives the function code of query from its first keyword
+func functionCodeOf(query string) functionCode {
+	fields := strings.Fields(strings.TrimLeft(query, "( \t\r\n"))
+	if len(fields) == 0 {
+		return fcNil
+	}
+	switch strings.ToUpper(fields[0]) {
+	case "SELECT", "WITH":
+		return fcSelect
+	case "INSERT", "UPSERT", "REPLACE":
+		return fcInsert
+	case "UPDATE", "MERGE":
+		return fcUpdate
+	case "DELETE":
+		return fcDelete
+	case "CALL":
+		return fcDBProcedureCall
+	case "CREATE", "DROP", "ALTER", "RENAME", "COMMENT", "GRANT", "REVOKE", "TRUNCATE":
+		return fcDDL
+	}
+	return fcNil
+}
+
+// encodeResultMetadata describes columns, the column names are written after the fields
+func encodeResultMetadata(columns []Column) []byte {
+	e, names := &encoder{}, &encoder{}
+	for _, c := range columns {
+		options := int8(1) // mandatory
+		if c.Nullable {
+			options = 2
+		}
+		e.int8(options)
+		e.byte(byte(c.Type))
+		e.int16(int16(c.Scale))
+		e.int16(int16(c.Length))
+		e.zeroes(2)
+		e.uint32(noNameOffs) // table name
+		e.uint32(noNameOffs) // schema name
+		offset := uint32(len(names.b))
+		e.uint32(offset) // column name
+		e.uint32(offset) // column display name
+		names.shortBytes(encodeCESU8(c.Name))
+	}
+	e.bytes(names.b)
+	return e.b
+}
+
+// encodeParameterMetadata describes unnamed input parameters
+func encodeParameterMetadata(params []Type) []byte {
+	e := &encoder{}
+	for _, t := range params {
+		e.int8(2) // nullable
+		e.byte(byte(t))
+		e.int8(1) // input parameter
+		e.zeroes(1)
+		e.uint32(noNameOffs)
+		e.int16(0) // length
+		e.int16(0) // fraction
+		e.zeroes(4)
+	}
+	return e.b
+}
@@ -0,0 +1,161 @@
+package hanatest_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/SAP/go-hdb/driver"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	hdb "gorm.io/driver/hana/hdb"
+	"gorm.io/driver/hana/hdb/hanatest"
+)
+
+// account has no identity column, go-hdb does not report the ids of inserted rows
+type account struct {
+	ID        uint `gorm:"autoIncrement:false"`
+	Name      string
+	Active    bool
+	Score     float64
+	Balance   hdb.Decimal
+	CreatedAt time.Time
+}
+
+func openServer(t *testing.T, script *hanatest.Script) (*gorm.DB, func()) {
+	srv := hanatest.NewServer(script)
+	db, err := gorm.Open(hdb.Open(srv.DSN), &gorm.Config{Logger: logger.Discard})
+	if !assert.Nil(t, err) {
+		srv.Close()
+		t.FailNow()
+	}
+	return db, func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+		srv.Close()
+	}
+}
+
+func TestServerCreate(t *testing.T) {
+	createdAt := time.Date(2022, 3, 14, 15, 9, 26, 535897900, time.UTC)
+	balance, _ := hdb.ParseDecimal("1234.56")
+
+	script := hanatest.NewScript()
+	script.Expect(`INSERT INTO "accounts" ("id","name","active","score","balance","created_at") VALUES (?,?,?,?,?,?)`).
+		WithArgs(7, "jinzhu", true, 1.5, balance, createdAt).
+		ReturnsRowsAffected(1)
+	script.ExpectIdentity(42)
+
+	db, closeDB := openServer(t, script)
+	defer closeDB()
+
+	a := account{ID: 7, Name: "jinzhu", Active: true, Score: 1.5, Balance: balance, CreatedAt: createdAt}
+	result := db.Create(&a)
+	assert.Nil(t, result.Error)
+	assert.Equal(t, int64(1), result.RowsAffected)
+
+	var id int64
+	assert.Nil(t, db.Raw(hanatest.IdentityQuery).Scan(&id).Error)
+	assert.Equal(t, int64(42), id)
+	assert.Nil(t, script.ExpectationsWereMet())
+}
+
+func TestServerQuery(t *testing.T) {
+	createdAt := time.Date(2022, 3, 14, 15, 9, 26, 0, time.UTC)
+
+	script := hanatest.NewScript()
+	script.Expect(`SELECT * FROM "accounts" WHERE name = ?`).
+		WithArgs("jinzhu").
+		ReturnsColumns(
+			hanatest.Column{Name: "id", Type: hanatest.BigInt},
+			hanatest.Column{Name: "name", Type: hanatest.NVarchar, Length: 256},
+			hanatest.Column{Name: "active", Type: hanatest.Boolean},
+			hanatest.Column{Name: "score", Type: hanatest.Double},
+			hanatest.Column{Name: "balance", Type: hanatest.Decimal, Length: 38, Scale: 2},
+			hanatest.Column{Name: "created_at", Type: hanatest.Timestamp, Nullable: true},
+		).
+		ReturnsRow(1, "jinzhu", true, 1.5, "1234.56", createdAt).
+		ReturnsRow(2, "jinzhu", false, nil, "-0.5", nil)
+
+	db, closeDB := openServer(t, script)
+	defer closeDB()
+
+	var accounts []account
+	assert.Nil(t, db.Where("name = ?", "jinzhu").Find(&accounts).Error)
+	if assert.Len(t, accounts, 2) {
+		assert.Equal(t, uint(1), accounts[0].ID)
+		assert.Equal(t, "jinzhu", accounts[0].Name)
+		assert.True(t, accounts[0].Active)
+		assert.Equal(t, 1.5, accounts[0].Score)
+		assert.Equal(t, "1234.56", accounts[0].Balance.String())
+		assert.True(t, createdAt.Equal(accounts[0].CreatedAt))
+		assert.False(t, accounts[1].Active)
+		assert.Equal(t, "-0.5", accounts[1].Balance.String())
+		assert.True(t, accounts[1].CreatedAt.IsZero())
+	}
+	assert.Nil(t, script.ExpectationsWereMet())
+}
+
+func TestServerError(t *testing.T) {
+	script := hanatest.NewScript()
+	script.ExpectRegexp(`^INSERT INTO "accounts"`).
+		WithArgs(0, "jinzhu", false, 0.0, hanatest.Any(hanatest.Decimal), hanatest.Any(hanatest.Timestamp)).
+		ReturnsError(&hanatest.Error{Code: 301, SQLState: "23000", Text: "unique constraint violated"})
+
+	db, closeDB := openServer(t, script)
+	defer closeDB()
+
+	err := db.Create(&account{Name: "jinzhu"}).Error
+	var hdbErr driver.Error
+	if assert.True(t, errors.As(err, &hdbErr)) {
+		assert.Equal(t, 301, hdbErr.Code())
+		assert.True(t, hdbErr.IsError())
+	}
+	assert.Nil(t, script.ExpectationsWereMet())
+}
+
+func TestServerUnexpectedStatement(t *testing.T) {
+	script := hanatest.NewScript()
+	script.Expect(`DELETE FROM "accounts" WHERE "accounts"."id" = ?`).WithArgs(1)
+
+	db, closeDB := openServer(t, script)
+	defer closeDB()
+
+	assert.NotNil(t, db.Delete(&account{}, 2).Error)
+	assert.NotNil(t, script.ExpectationsWereMet())
+}
+
+func TestServerTransaction(t *testing.T) {
+	script := hanatest.NewScript()
+	script.Expect(`UPDATE "accounts" SET "active"=? WHERE name = ?`).
+		WithArgs(true, "jinzhu").
+		ReturnsRowsAffected(3)
+
+	db, closeDB := openServer(t, script)
+	defer closeDB()
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&account{}).Where("name = ?", "jinzhu").Update("active", true)
+		assert.Equal(t, int64(3), result.RowsAffected)
+		return result.Error
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, script.ExpectationsWereMet())
+}
+
+func TestServerAuthentication(t *testing.T) {
+	srv := hanatest.NewUnstartedServer(hanatest.NewScript())
+	srv.Password = "secret"
+	srv.Start()
+	defer srv.Close()
+
+	_, err := gorm.Open(hdb.Open(srv.DSN), &gorm.Config{Logger: logger.Discard})
+	assert.Nil(t, err)
+
+	dsn := "hdb://" + hanatest.DefaultUsername + ":wrong@" + srv.Listener.Addr().String()
+	_, err = gorm.Open(hdb.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	assert.NotNil(t, err)
+}
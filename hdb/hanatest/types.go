@@ -0,0 +1,448 @@
+package hanatest
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"time"
+)
+
+// Type is the type code of a column or parameter on the wire
+type Type byte
+
+// Supported column and parameter types, LOB types are not supported
+const (
+	TinyInt    Type = 0x01
+	SmallInt   Type = 0x02
+	Integer    Type = 0x03
+	BigInt     Type = 0x04
+	Decimal    Type = 0x05
+	Real       Type = 0x06
+	Double     Type = 0x07
+	Varchar    Type = 0x09
+	NVarchar   Type = 0x0B
+	VarBinary  Type = 0x0D
+	Boolean    Type = 0x1C
+	ShortText  Type = 0x34
+	Alphanum   Type = 0x37
+	Timestamp  Type = 0x3D // LONGDATE
+	SecondDate Type = 0x3E
+	Date       Type = 0x3F // DAYDATE
+	Time       Type = 0x40 // SECONDTIME
+)
+
+var typeNames = map[Type]string{
+	TinyInt:    "TINYINT",
+	SmallInt:   "SMALLINT",
+	Integer:    "INTEGER",
+	BigInt:     "BIGINT",
+	Decimal:    "DECIMAL",
+	Real:       "REAL",
+	Double:     "DOUBLE",
+	Varchar:    "VARCHAR",
+	NVarchar:   "NVARCHAR",
+	VarBinary:  "VARBINARY",
+	Boolean:    "BOOLEAN",
+	ShortText:  "SHORTTEXT",
+	Alphanum:   "ALPHANUM",
+	Timestamp:  "TIMESTAMP",
+	SecondDate: "SECONDDATE",
+	Date:       "DATE",
+	Time:       "TIME",
+}
+
+func (t Type) String() string {
+	if name, ok := typeNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("Type(%d)", byte(t))
+}
+
+// Column is a result column of a query
+type Column struct {
+	Name string
+	Type Type
+	// Length is the length of character and binary columns or the precision of decimals
+	Length int
+	// Scale is the scale of decimals
+	Scale    int
+	Nullable bool
+}
+
+// null values of the fixed size types
+const (
+	realNullValue       uint32 = math.MaxUint32
+	doubleNullValue     uint64 = math.MaxUint64
+	longdateNullValue   int64  = 3155380704000000001
+	seconddateNullValue int64  = 315538070401
+	daydateNullValue    int32  = 3652062
+	secondtimeNullValue int32  = 86402
+)
+
+const (
+	// unixDaydate is the HANA day date of 1970-01-01
+	unixDaydate   = 719165
+	secondsPerDay = 24 * 60 * 60
+	// decimalBias is the exponent bias of 128 bit decimals
+	decimalBias = 6176
+	// decimalDigits is the maximum number of fractional digits sent for decimals
+	decimalDigits = 34
+)
+
+// The date types are converted with the proleptic Gregorian calendar, go-hdb
+// uses the Julian calendar before 1582-10-15.
+
+func daydate(t time.Time) int64 {
+	unix := t.Unix()
+	days := unix / secondsPerDay
+	if unix%secondsPerDay < 0 {
+		days--
+	}
+	return days + unixDaydate
+}
+
+func secondsOfDay(t time.Time) int64 {
+	return int64(t.Hour()*3600 + t.Minute()*60 + t.Second())
+}
+
+func daydateTime(daydate int64) time.Time {
+	return time.Unix((daydate-unixDaydate)*secondsPerDay, 0).UTC()
+}
+
+func longdateTime(longdate int64) time.Time {
+	const dayFactor = secondsPerDay * 10000000
+	longdate--
+	return daydateTime(longdate/dayFactor + 1).Add(time.Duration(longdate%dayFactor) * 100)
+}
+
+func seconddateTime(seconddate int64) time.Time {
+	seconddate--
+	return daydateTime(seconddate/secondsPerDay + 1).Add(time.Duration(seconddate%secondsPerDay) * time.Second)
+}
+
+func secondtimeTime(secondtime int32) time.Time {
+	return time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration(secondtime-1) * time.Second)
+}
+
+var ten = big.NewInt(10)
+
+// encodeDecimal writes r as 128 bit decimal, fractions are cut after decimalDigits digits
+func encodeDecimal(e *encoder, r *big.Rat) error {
+	n := new(big.Rat).Set(r)
+	exp := 0
+	for !n.IsInt() && exp > -decimalDigits {
+		n.Mul(n, new(big.Rat).SetInt(ten))
+		exp--
+	}
+	m := new(big.Int).Quo(n.Num(), n.Denom())
+	if m.BitLen() > 113 {
+		return fmt.Errorf("hanatest: decimal %s out of range", r.FloatString(decimalDigits))
+	}
+
+	var p [16]byte
+	magnitude := m.Bytes() // big endian
+	for i, b := range magnitude {
+		p[len(magnitude)-1-i] = b
+	}
+	exp += decimalBias
+	p[14] |= byte(exp) << 1
+	p[15] = byte(uint16(exp) >> 7)
+	if m.Sign() < 0 {
+		p[15] |= 0x80
+	}
+	e.bytes(p[:])
+	return nil
+}
+
+func decodeDecimal(p []byte) *big.Rat {
+	var b [16]byte
+	copy(b[:], p)
+	neg := b[15]&0x80 != 0
+	exp := int((((uint16(b[15])<<8)|uint16(b[14]))<<1)>>2) - decimalBias
+	b[14] &= 0x01
+
+	magnitude := make([]byte, 15)
+	for i := range magnitude {
+		magnitude[i] = b[14-i]
+	}
+	m := new(big.Int).SetBytes(magnitude)
+	if neg {
+		m.Neg(m)
+	}
+
+	r := new(big.Rat).SetInt(m)
+	scale := new(big.Rat).SetInt(new(big.Int).Exp(ten, big.NewInt(int64(abs(exp))), nil))
+	if exp < 0 {
+		return r.Quo(r, scale)
+	}
+	return r.Mul(r, scale)
+}
+
+func abs(i int) int {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
+
+func asInt64(v interface{}) (int64, error) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(rv.Uint()), nil
+	case reflect.Bool:
+		if rv.Bool() {
+			return 1, nil
+		}
+		return 0, nil
+	}
+	return 0, fmt.Errorf("hanatest: invalid integer value %T", v)
+}
+
+func asFloat64(v interface{}) (float64, error) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), nil
+	}
+	i, err := asInt64(v)
+	if err != nil {
+		return 0, fmt.Errorf("hanatest: invalid float value %T", v)
+	}
+	return float64(i), nil
+}
+
+func asRat(v interface{}) (*big.Rat, error) {
+	switch v := v.(type) {
+	case *big.Rat:
+		return v, nil
+	case string:
+		if r, ok := new(big.Rat).SetString(v); ok {
+			return r, nil
+		}
+		return nil, fmt.Errorf("hanatest: invalid decimal value %q", v)
+	case float32, float64:
+		f, _ := asFloat64(v)
+		return new(big.Rat).SetFloat64(f), nil
+	}
+	i, err := asInt64(v)
+	if err != nil {
+		return nil, fmt.Errorf("hanatest: invalid decimal value %T", v)
+	}
+	return new(big.Rat).SetInt64(i), nil
+}
+
+func asBytes(v interface{}) []byte {
+	switch v := v.(type) {
+	case []byte:
+		return v
+	case string:
+		return []byte(v)
+	}
+	return []byte(fmt.Sprint(v))
+}
+
+func asTime(v interface{}) (time.Time, error) {
+	t, ok := v.(time.Time)
+	if !ok {
+		return t, fmt.Errorf("hanatest: invalid time value %T", v)
+	}
+	return t.UTC(), nil
+}
+
+func isNumeric(p []byte) bool {
+	for _, b := range p {
+		if b < '0' || b > '9' {
+			return false
+		}
+	}
+	return len(p) > 0
+}
+
+// encodeValue writes v as result value of type t
+func encodeValue(e *encoder, t Type, v interface{}, dfv int) (err error) {
+	if valuer, ok := v.(driver.Valuer); ok {
+		if v, err = valuer.Value(); err != nil {
+			return err
+		}
+	}
+	if v == nil {
+		return encodeNull(e, t)
+	}
+
+	switch t {
+	case TinyInt, SmallInt, Integer, BigInt:
+		i, err := asInt64(v)
+		if err != nil {
+			return err
+		}
+		e.byte(1)
+		switch t {
+		case TinyInt:
+			e.byte(byte(i))
+		case SmallInt:
+			e.int16(int16(i))
+		case Integer:
+			e.int32(int32(i))
+		default:
+			e.int64(i)
+		}
+	case Real, Double:
+		f, err := asFloat64(v)
+		if err != nil {
+			return err
+		}
+		if t == Real {
+			e.float32(float32(f))
+		} else {
+			e.float64(f)
+		}
+	case Decimal:
+		r, err := asRat(v)
+		if err != nil {
+			return err
+		}
+		return encodeDecimal(e, r)
+	case Varchar, VarBinary:
+		e.varBytes(asBytes(v))
+	case NVarchar, ShortText:
+		e.varBytes(encodeCESU8(string(asBytes(v))))
+	case Alphanum:
+		p := asBytes(v)
+		if dfv == 1 {
+			e.varBytes(p)
+			break
+		}
+		// the value is preceded by its length, with the high bit set for numeric values
+		indicator := byte(len(p))
+		if isNumeric(p) {
+			indicator |= 0x80
+		}
+		e.varBytes(append([]byte{indicator}, p...))
+	case Boolean:
+		b, ok := v.(bool)
+		if !ok {
+			return fmt.Errorf("hanatest: invalid boolean value %T", v)
+		}
+		if b {
+			e.byte(2)
+		} else {
+			e.byte(0)
+		}
+	case Timestamp, SecondDate, Date, Time:
+		t2, err := asTime(v)
+		if err != nil {
+			return err
+		}
+		switch t {
+		case Timestamp:
+			e.int64(((daydate(t2)-1)*secondsPerDay+secondsOfDay(t2))*10000000 + int64(t2.Nanosecond()/100) + 1)
+		case SecondDate:
+			e.int64((daydate(t2)-1)*secondsPerDay + secondsOfDay(t2) + 1)
+		case Date:
+			e.int32(int32(daydate(t2)))
+		default:
+			e.int32(int32(secondsOfDay(t2) + 1))
+		}
+	default:
+		return fmt.Errorf("hanatest: unsupported type %s", t)
+	}
+	return nil
+}
+
+func encodeNull(e *encoder, t Type) error {
+	switch t {
+	case TinyInt, SmallInt, Integer, BigInt:
+		e.byte(0)
+	case Real:
+		e.uint32(realNullValue)
+	case Double:
+		e.uint64(doubleNullValue)
+	case Decimal:
+		var p [16]byte
+		p[15] = 0x70
+		e.bytes(p[:])
+	case Varchar, VarBinary, NVarchar, ShortText, Alphanum:
+		e.byte(255)
+	case Boolean:
+		e.byte(1)
+	case Timestamp:
+		e.int64(longdateNullValue)
+	case SecondDate:
+		e.int64(seconddateNullValue)
+	case Date:
+		e.int32(daydateNullValue)
+	case Time:
+		e.int32(secondtimeNullValue)
+	default:
+		return fmt.Errorf("hanatest: unsupported type %s", t)
+	}
+	return nil
+}
+
+// decodeParam reads a parameter value of type t, preceded by its type code
+func decodeParam(d *decoder, t Type) (interface{}, error) {
+	if typeCode := d.byte(); typeCode&0x80 != 0 {
+		return nil, d.err // null value
+	}
+
+	var v interface{}
+	switch t {
+	case TinyInt:
+		v = int64(d.byte())
+	case SmallInt:
+		v = int64(d.int16())
+	case Integer:
+		v = int64(d.int32())
+	case BigInt:
+		v = d.int64()
+	case Real:
+		v = float64(d.float32())
+	case Double:
+		v = d.float64()
+	case Decimal:
+		v = decodeDecimal(d.next(16))
+	case Varchar, Alphanum, NVarchar, ShortText, VarBinary:
+		p, ok := d.varBytes()
+		switch {
+		case !ok:
+		case t == NVarchar || t == ShortText:
+			v = decodeCESU8(p)
+		case t == VarBinary:
+			v = append([]byte{}, p...)
+		default:
+			v = string(p)
+		}
+	case Boolean:
+		switch d.byte() {
+		case 0:
+			v = false
+		case 1:
+		default:
+			v = true
+		}
+	case Timestamp:
+		if longdate := d.int64(); longdate != longdateNullValue {
+			v = longdateTime(longdate)
+		}
+	case SecondDate:
+		if seconddate := d.int64(); seconddate != seconddateNullValue {
+			v = seconddateTime(seconddate)
+		}
+	case Date:
+		if daydate := d.int32(); daydate != daydateNullValue {
+			v = daydateTime(int64(daydate))
+		}
+	case Time:
+		if secondtime := d.int32(); secondtime != secondtimeNullValue {
+			v = secondtimeTime(secondtime)
+		}
+	default:
+		return nil, fmt.Errorf("hanatest: unsupported parameter type %s", t)
+	}
+	return v, d.err
+}
@@ -0,0 +1,415 @@
+package hanatest
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/SAP/go-hdb/driver/unicode/cesu8"
+)
+
+// The wire format follows the HANA SQL command network protocol as implemented by
+// the go-hdb client: little endian messages with a single segment of parts, each part
+// padded to 8 bytes.
+
+type messageType int8
+
+const (
+	mtExecuteDirect   messageType = 2
+	mtPrepare         messageType = 3
+	mtExecute         messageType = 13
+	mtAuthenticate    messageType = 65
+	mtConnect         messageType = 66
+	mtCommit          messageType = 67
+	mtRollback        messageType = 68
+	mtCloseResultset  messageType = 69
+	mtDropStatementID messageType = 70
+	mtDisconnect      messageType = 77
+)
+
+type segmentKind int8
+
+const (
+	skReply segmentKind = 2
+	skError segmentKind = 5
+)
+
+type functionCode int16
+
+const (
+	fcNil             functionCode = 0
+	fcDDL             functionCode = 1
+	fcInsert          functionCode = 2
+	fcUpdate          functionCode = 3
+	fcDelete          functionCode = 4
+	fcSelect          functionCode = 5
+	fcDBProcedureCall functionCode = 8
+)
+
+type partKind int8
+
+const (
+	pkCommand           partKind = 3
+	pkResultset         partKind = 5
+	pkError             partKind = 6
+	pkStatementID       partKind = 10
+	pkRowsAffected      partKind = 12
+	pkResultsetID       partKind = 13
+	pkParameters        partKind = 32
+	pkAuthentication    partKind = 33
+	pkConnectOptions    partKind = 42
+	pkParameterMetadata partKind = 47
+	pkResultMetadata    partKind = 48
+)
+
+const (
+	paLastPacket      int8 = 0x01
+	paResultsetClosed int8 = 0x10
+)
+
+const (
+	initRequestSize   = 14
+	messageHeaderSize = 32
+	segmentHeaderSize = 24
+	partHeaderSize    = 16
+)
+
+// connect options
+const (
+	coDataFormatVersion2 int8 = 23
+	coFullVersionString  int8 = 44
+	coDatabaseName       int8 = 45
+)
+
+// option type codes
+const (
+	otTinyint  = 0x01
+	otInteger  = 0x03
+	otBigint   = 0x04
+	otDouble   = 0x07
+	otBoolean  = 0x1C
+	otString   = 0x1D
+	otBstring  = 0x21
+	noNameOffs = 0xFFFFFFFF
+)
+
+var errShortBuffer = errors.New("hanatest: unexpected end of message")
+
+func padBytes(size int) int {
+	if r := size % 8; r != 0 {
+		return 8 - r
+	}
+	return 0
+}
+
+// encoder appends little endian values to a buffer
+type encoder struct {
+	b []byte
+}
+
+func (e *encoder) byte(v byte)    { e.b = append(e.b, v) }
+func (e *encoder) int8(v int8)    { e.b = append(e.b, byte(v)) }
+func (e *encoder) bytes(p []byte) { e.b = append(e.b, p...) }
+func (e *encoder) zeroes(cnt int) { e.b = append(e.b, make([]byte, cnt)...) }
+func (e *encoder) int16(v int16)  { e.uint16(uint16(v)) }
+func (e *encoder) int32(v int32)  { e.uint32(uint32(v)) }
+func (e *encoder) int64(v int64)  { e.uint64(uint64(v)) }
+
+func (e *encoder) uint16(v uint16) {
+	var p [2]byte
+	binary.LittleEndian.PutUint16(p[:], v)
+	e.b = append(e.b, p[:]...)
+}
+
+func (e *encoder) uint32(v uint32) {
+	var p [4]byte
+	binary.LittleEndian.PutUint32(p[:], v)
+	e.b = append(e.b, p[:]...)
+}
+
+func (e *encoder) uint64(v uint64) {
+	var p [8]byte
+	binary.LittleEndian.PutUint64(p[:], v)
+	e.b = append(e.b, p[:]...)
+}
+func (e *encoder) float32(v float32) { e.uint32(math.Float32bits(v)) }
+func (e *encoder) float64(v float64) { e.uint64(math.Float64bits(v)) }
+
+// shortBytes writes p with a one byte length
+func (e *encoder) shortBytes(p []byte) {
+	e.byte(byte(len(p)))
+	e.bytes(p)
+}
+
+// varBytes writes p with a length indicator
+func (e *encoder) varBytes(p []byte) {
+	switch size := len(p); {
+	case size <= 245:
+		e.byte(byte(size))
+	case size <= math.MaxInt16:
+		e.byte(246)
+		e.int16(int16(size))
+	default:
+		e.byte(247)
+		e.int32(int32(size))
+	}
+	e.bytes(p)
+}
+
+// decoder reads little endian values from a buffer
+type decoder struct {
+	b   []byte
+	err error
+}
+
+func (d *decoder) next(size int) []byte {
+	if d.err != nil {
+		return make([]byte, size)
+	}
+	if size < 0 || size > len(d.b) {
+		d.err = errShortBuffer
+		d.b = nil
+		return make([]byte, size)
+	}
+	p := d.b[:size]
+	d.b = d.b[size:]
+	return p
+}
+
+func (d *decoder) skip(cnt int)       { d.next(cnt) }
+func (d *decoder) byte() byte         { return d.next(1)[0] }
+func (d *decoder) int8() int8         { return int8(d.byte()) }
+func (d *decoder) int16() int16       { return int16(binary.LittleEndian.Uint16(d.next(2))) }
+func (d *decoder) int32() int32       { return int32(binary.LittleEndian.Uint32(d.next(4))) }
+func (d *decoder) uint32() uint32     { return binary.LittleEndian.Uint32(d.next(4)) }
+func (d *decoder) int64() int64       { return int64(binary.LittleEndian.Uint64(d.next(8))) }
+func (d *decoder) uint64() uint64     { return binary.LittleEndian.Uint64(d.next(8)) }
+func (d *decoder) float32() float32   { return math.Float32frombits(d.uint32()) }
+func (d *decoder) float64() float64   { return math.Float64frombits(d.uint64()) }
+func (d *decoder) shortBytes() []byte { return d.next(int(d.byte())) }
+
+// varBytes reads a value with a length indicator, null is reported by ok false
+func (d *decoder) varBytes() (p []byte, ok bool) {
+	switch ind := d.byte(); {
+	case ind <= 245:
+		return d.next(int(ind)), true
+	case ind == 246:
+		return d.next(int(d.int16())), true
+	case ind == 247:
+		return d.next(int(d.int32())), true
+	case ind == 255:
+		return nil, false
+	default:
+		d.err = fmt.Errorf("hanatest: invalid length indicator %d", ind)
+		return nil, false
+	}
+}
+
+func encodeCESU8(s string) []byte {
+	p := make([]byte, cesu8.StringSize(s))
+	n := 0
+	for _, r := range s {
+		n += cesu8.EncodeRune(p[n:], r)
+	}
+	return p
+}
+
+func decodeCESU8(p []byte) string {
+	var builder strings.Builder
+	for len(p) > 0 {
+		r, n := cesu8.DecodeRune(p)
+		if n <= 0 {
+			n = 1
+		}
+		builder.WriteRune(r)
+		p = p[n:]
+	}
+	return builder.String()
+}
+
+// part is a part of a request or reply message
+type part struct {
+	kind       partKind
+	attributes int8
+	argCount   int
+	data       []byte
+}
+
+// request is a message sent by the client
+type request struct {
+	sessionID   int64
+	messageType messageType
+	commit      bool
+	parts       []part
+}
+
+// part returns the data of the first part of kind
+func (r *request) part(kind partKind) (part, bool) {
+	for _, p := range r.parts {
+		if p.kind == kind {
+			return p, true
+		}
+	}
+	return part{}, false
+}
+
+// command returns the SQL text of the request
+func (r *request) command() (string, error) {
+	p, ok := r.part(pkCommand)
+	if !ok {
+		return "", fmt.Errorf("hanatest: message type %d without command", r.messageType)
+	}
+	return decodeCESU8(p.data), nil
+}
+
+func readRequest(rd io.Reader) (*request, error) {
+	header := make([]byte, messageHeaderSize)
+	if _, err := io.ReadFull(rd, header); err != nil {
+		return nil, err
+	}
+	d := &decoder{b: header}
+	req := &request{sessionID: d.int64()}
+	d.int32() // packet count
+	varPartLength := d.uint32()
+	d.uint32() // var part size
+	numSegments := int(d.int16())
+
+	body := make([]byte, varPartLength)
+	if _, err := io.ReadFull(rd, body); err != nil {
+		return nil, err
+	}
+	d = &decoder{b: body}
+	for i := 0; i < numSegments; i++ {
+		d.int32() // segment length
+		d.int32() // segment offset
+		numParts := int(d.int16())
+		d.int16() // segment number
+		d.int8()  // segment kind
+		req.messageType = messageType(d.int8())
+		req.commit = d.byte() != 0
+		d.int8() // command options
+		d.skip(8)
+
+		for j := 0; j < numParts; j++ {
+			p := part{kind: partKind(d.int8()), attributes: d.int8()}
+			p.argCount = int(d.int16())
+			if bigArgCount := d.int32(); bigArgCount != 0 {
+				p.argCount = int(bigArgCount)
+			}
+			bufferLength := int(d.int32())
+			d.int32() // buffer size
+			p.data = d.next(bufferLength)
+			if pad := padBytes(bufferLength); pad <= len(d.b) {
+				d.skip(pad)
+			}
+			req.parts = append(req.parts, p)
+		}
+	}
+	return req, d.err
+}
+
+// reply is a message sent by the server
+type reply struct {
+	kind         segmentKind
+	functionCode functionCode
+	parts        []part
+}
+
+func (r *reply) add(kind partKind, attributes int8, argCount int, data []byte) {
+	r.parts = append(r.parts, part{kind: kind, attributes: attributes, argCount: argCount, data: data})
+}
+
+func writeReply(wr io.Writer, sessionID int64, r *reply) error {
+	size := segmentHeaderSize
+	for _, p := range r.parts {
+		size += partHeaderSize + len(p.data) + padBytes(len(p.data))
+	}
+
+	e := &encoder{b: make([]byte, 0, messageHeaderSize+size)}
+	e.int64(sessionID)
+	e.int32(0) // packet count
+	e.uint32(uint32(size))
+	e.uint32(uint32(size))
+	e.int16(1) // number of segments
+	e.zeroes(10)
+
+	kind := r.kind
+	if kind == 0 {
+		kind = skReply
+	}
+	e.int32(int32(size))
+	e.int32(0) // segment offset
+	e.int16(int16(len(r.parts)))
+	e.int16(1) // segment number
+	e.int8(int8(kind))
+	e.zeroes(1)
+	e.int16(int16(r.functionCode))
+	e.zeroes(8)
+
+	bufferSize := size - segmentHeaderSize
+	for _, p := range r.parts {
+		if p.argCount > math.MaxInt16 {
+			return fmt.Errorf("hanatest: number of arguments %d exceeds %d", p.argCount, math.MaxInt16)
+		}
+		e.int8(int8(p.kind))
+		e.int8(p.attributes)
+		e.int16(int16(p.argCount))
+		e.int32(0) // big argument count
+		e.int32(int32(len(p.data)))
+		e.int32(int32(bufferSize))
+		e.bytes(p.data)
+		e.zeroes(padBytes(len(p.data)))
+		bufferSize -= partHeaderSize + len(p.data) + padBytes(len(p.data))
+	}
+
+	_, err := wr.Write(e.b)
+	return err
+}
+
+// decodeOptions decodes the options of connect option parts
+func decodeOptions(p part) (map[int8]interface{}, error) {
+	options := map[int8]interface{}{}
+	d := &decoder{b: p.data}
+	for i := 0; i < p.argCount && d.err == nil; i++ {
+		key := d.int8()
+		switch typeCode := d.byte(); typeCode {
+		case otBoolean:
+			options[key] = d.byte() != 0
+		case otTinyint:
+			options[key] = d.int8()
+		case otInteger:
+			options[key] = d.int32()
+		case otBigint:
+			options[key] = d.int64()
+		case otDouble:
+			options[key] = d.float64()
+		case otString, otBstring:
+			options[key] = string(d.next(int(d.int16())))
+		default:
+			return nil, fmt.Errorf("hanatest: invalid option type code %d", typeCode)
+		}
+	}
+	return options, d.err
+}
+
+// encodeOptions encodes int32 and string options in the order of keys
+func encodeOptions(keys []int8, options map[int8]interface{}) []byte {
+	e := &encoder{}
+	for _, key := range keys {
+		switch v := options[key].(type) {
+		case int32:
+			e.int8(key)
+			e.byte(otInteger)
+			e.int32(v)
+		case string:
+			e.int8(key)
+			e.byte(otString)
+			e.int16(int16(len(v)))
+			e.bytes([]byte(v))
+		}
+	}
+	return e.b
+}